@@ -0,0 +1,160 @@
+package rushia
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Aggregate is an aggregate function (e.g. `COUNT`, `SUM`) that is able to be used in `Select`, `Having` and `OrderBy`,
+// the plain string columns will be treated as the identifiers. The alias is only rendered in the selected columns.
+type Aggregate struct {
+	function  string
+	distinct  bool
+	columns   []interface{}
+	separator *string
+	orders    []order
+	alias     string
+}
+
+// newAggregate creates an aggregate function with the columns, the plain string columns are converted to the identifiers.
+func newAggregate(function string, columns ...interface{}) *Aggregate {
+	a := &Aggregate{
+		function: function,
+	}
+	for _, v := range columns {
+		if s, ok := v.(string); ok {
+			v = Ident(s)
+		}
+		a.columns = append(a.columns, v)
+	}
+	return a
+}
+
+// Count creates a `COUNT` aggregate function, it counts all the rows (`COUNT(*)`) if there's no column.
+func Count(columns ...interface{}) *Aggregate {
+	if len(columns) == 0 {
+		return newAggregate("COUNT", Raw("*"))
+	}
+	return newAggregate("COUNT", columns...)
+}
+
+// CountDistinct creates a `COUNT(DISTINCT ...)` aggregate function.
+func CountDistinct(columns ...interface{}) *Aggregate {
+	return Count(columns...).Distinct()
+}
+
+// Sum creates a `SUM` aggregate function.
+func Sum(column interface{}) *Aggregate {
+	return newAggregate("SUM", column)
+}
+
+// Avg creates an `AVG` aggregate function.
+func Avg(column interface{}) *Aggregate {
+	return newAggregate("AVG", column)
+}
+
+// Min creates a `MIN` aggregate function.
+func Min(column interface{}) *Aggregate {
+	return newAggregate("MIN", column)
+}
+
+// Max creates a `MAX` aggregate function.
+func Max(column interface{}) *Aggregate {
+	return newAggregate("MAX", column)
+}
+
+// GroupConcat creates a `GROUP_CONCAT` aggregate function, it will be built as `STRING_AGG` on PostgreSQL.
+// Use `Separator` and `OrderBy` to specify the separator and the order of the concatenated values.
+func GroupConcat(column interface{}) *Aggregate {
+	return newAggregate("GROUP_CONCAT", column)
+}
+
+// Grouping creates a `GROUPING` function that tells if the columns were aggregated by `WITH ROLLUP`, `GROUPING SETS` or `CUBE`.
+func Grouping(columns ...interface{}) *Aggregate {
+	return newAggregate("GROUPING", columns...)
+}
+
+// copy creates a copy of the aggregate function, so the original one won't be modified.
+func (a *Aggregate) copy() *Aggregate {
+	b := *a
+	b.columns = append([]interface{}{}, a.columns...)
+	b.orders = append([]order{}, a.orders...)
+	return &b
+}
+
+// As gives the aggregate function an alias.
+func (a *Aggregate) As(alias string) *Aggregate {
+	b := a.copy()
+	b.alias = alias
+	return b
+}
+
+// Distinct aggregates the distinct values only.
+func (a *Aggregate) Distinct() *Aggregate {
+	b := a.copy()
+	b.distinct = true
+	return b
+}
+
+// Separator sets the separator of the `GROUP_CONCAT` function, the default separator is `,`.
+func (a *Aggregate) Separator(separator string) *Aggregate {
+	b := a.copy()
+	b.separator = &separator
+	return b
+}
+
+// OrderBy sorts the values of the `GROUP_CONCAT` function, the plain string column will be treated as the identifier.
+func (a *Aggregate) OrderBy(column interface{}, direction ...OrderDirection) *Aggregate {
+	b := a.copy()
+	if s, ok := column.(string); ok {
+		column = Ident(s)
+	}
+	o := order{column: column}
+	if len(direction) != 0 {
+		o.direction = direction[0]
+	}
+	b.orders = append(b.orders, o)
+	return b
+}
+
+// buildAggregate builds the aggregate function and binds the parameters to the query, the alias will be appended if `withAlias` is true.
+func (q *Query) buildAggregate(a *Aggregate, withAlias bool) string {
+	function := a.function
+	columns := q.bindParams(a.columns, &bindOptions{keepStringValue: true})
+	if a.distinct {
+		columns = fmt.Sprintf("DISTINCT %s", columns)
+	}
+
+	var orders []string
+	for _, v := range a.orders {
+		column := q.buildOrderColumn(v)
+		if v.direction != "" {
+			column += fmt.Sprintf(" %s", v.direction)
+		}
+		orders = append(orders, column)
+	}
+	if a.function == "GROUP_CONCAT" {
+		separator := ","
+		if a.separator != nil {
+			separator = *a.separator
+		}
+		var orderQuery string
+		if len(orders) != 0 {
+			orderQuery = fmt.Sprintf(" ORDER BY %s", strings.Join(orders, ", "))
+		}
+		// GROUP_CONCAT(`Name` ORDER BY `Name` SEPARATOR ', ')
+		// STRING_AGG(`Name`, ', ' ORDER BY `Name`)
+		if q.dialect == DialectPostgreSQL {
+			function = "STRING_AGG"
+			columns = fmt.Sprintf("%s, %s%s", columns, quoteString(separator, q.dialect), orderQuery)
+		} else {
+			columns = fmt.Sprintf("%s%s SEPARATOR %s", columns, orderQuery, quoteString(separator, q.dialect))
+		}
+	}
+
+	query := fmt.Sprintf("%s(%s)", function, columns)
+	if withAlias && a.alias != "" {
+		query += fmt.Sprintf(" AS %s", quoteIdent(a.alias))
+	}
+	return query
+}
@@ -0,0 +1,152 @@
+package rushia
+
+import "reflect"
+
+// Clone creates a deep copy of the current query, including the joins, the conditions, the data,
+// the nested sub queries and the expressions, so the changes of the clone will never affect the original query.
+//
+// The values that are not maps, slices or the rushia types (e.g. the pointers to the structs) are still shared.
+func (q *Query) Clone() *Query {
+	if q == nil {
+		return nil
+	}
+	c := q.Copy()
+	c.table = cloneValue(q.table)
+	c.subQuery = q.subQuery.Clone()
+	c.wheres = cloneConditions(q.wheres)
+	c.havings = cloneConditions(q.havings)
+	for i, v := range c.unions {
+		c.unions[i].query = v.query.Clone()
+	}
+	c.data = cloneValue(q.data)
+	for i, v := range c.selects {
+		c.selects[i] = cloneValue(v)
+	}
+	for i, v := range c.joins {
+		c.joins[i].table = cloneValue(v.table)
+		c.joins[i].subQuery = v.subQuery.Clone()
+		c.joins[i].conditions = cloneConditions(v.conditions)
+	}
+	if q.duplicate != nil {
+		c.duplicate = cloneValue(q.duplicate).(H)
+	}
+	for i, v := range c.assignments {
+		c.assignments[i].value = cloneValue(v.value)
+	}
+	for i, v := range c.orders {
+		c.orders[i].field = cloneValue(v.field)
+		c.orders[i].column = cloneValue(v.column)
+		c.orders[i].values = cloneValues(v.values)
+	}
+	c.groups = cloneValues(q.groups)
+	for i, v := range c.groupingSets {
+		c.groupingSets[i] = cloneValues(v)
+	}
+	c.params = cloneValues(q.params)
+	return c
+}
+
+// cloneConditions deep copies the conditions with the arguments and the nested groups.
+func cloneConditions(conditions []condition) []condition {
+	if conditions == nil {
+		return nil
+	}
+	result := make([]condition, len(conditions))
+	for i, v := range conditions {
+		v.args = cloneValues(v.args)
+		v.group = cloneConditions(v.group)
+		if v.tuple != nil {
+			v.tuple = &tuple{
+				columns: append([]string{}, v.tuple.columns...),
+				values:  cloneValue(v.tuple.values),
+			}
+		}
+		result[i] = v
+	}
+	return result
+}
+
+// cloneValues deep copies the values of the slice.
+func cloneValues(values []interface{}) []interface{} {
+	if values == nil {
+		return nil
+	}
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = cloneValue(v)
+	}
+	return result
+}
+
+// cloneValue deep copies the sub queries, the expressions, the maps and the slices,
+// the other values are returned as-is.
+func cloneValue(v interface{}) interface{} {
+	switch j := v.(type) {
+	case nil:
+		return nil
+	case *Query:
+		return j.Clone()
+	case *Expr:
+		if j == nil {
+			return j
+		}
+		return &Expr{rawQuery: j.rawQuery, params: cloneValues(j.params), err: j.err}
+	case *Expression:
+		if j == nil {
+			return j
+		}
+		c := j.copy()
+		c.operands = cloneValues(j.operands)
+		c.subject = cloneValue(j.subject)
+		c.els = cloneValue(j.els)
+		for i, w := range c.whens {
+			c.whens[i] = when{condition: cloneValue(w.condition), value: cloneValue(w.value)}
+		}
+		return c
+	case *Aggregate:
+		if j == nil {
+			return j
+		}
+		c := j.copy()
+		c.columns = cloneValues(j.columns)
+		return c
+	case H:
+		if j == nil {
+			return j
+		}
+		c := make(H, len(j))
+		for k, w := range j {
+			c[k] = cloneValue(w)
+		}
+		return c
+	case map[string]interface{}:
+		return map[string]interface{}(cloneValue(H(j)).(H))
+	case []H:
+		if j == nil {
+			return j
+		}
+		c := make([]H, len(j))
+		for i, w := range j {
+			c[i] = cloneValue(w).(H)
+		}
+		return c
+	case []map[string]interface{}:
+		if j == nil {
+			return j
+		}
+		c := make([]map[string]interface{}, len(j))
+		for i, w := range j {
+			c[i] = cloneValue(w).(map[string]interface{})
+		}
+		return c
+	case []interface{}:
+		return cloneValues(j)
+	}
+	// The other slices (e.g. `[]int`, `[]byte`) are copied without going deeper.
+	if val := reflect.ValueOf(v); val.Kind() == reflect.Slice && !val.IsNil() {
+		c := reflect.MakeSlice(val.Type(), val.Len(), val.Len())
+		reflect.Copy(c, val)
+		return c.Interface()
+	}
+	return v
+}
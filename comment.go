@@ -0,0 +1,84 @@
+package rushia
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ContextTags extracts the tags (e.g. the trace id, the route) from the context for `TagContext`,
+// it's nil by default and could be set by the execution layer.
+var ContextTags func(ctx context.Context) map[string]string
+
+// Comment adds a comment to the query, it will be placed at the end of the query (e.g. `SELECT * FROM Users /* comment */`).
+// The comment delimiters in the comment are escaped so the comment can't be closed early,
+// and the question marks are encoded as `%3F` so they won't be taken as the placeholders.
+func (q *Query) Comment(comment string) *Query {
+	q.comments = append(q.comments, comment)
+	return q
+}
+
+// Tag adds the key-value tags to the query, they will be rendered as a sqlcommenter-style comment
+// at the end of the query (e.g. `SELECT * FROM Users /*route='%2Fusers',trace_id='abc'*/`).
+// The tag replaces the previous one with the same key.
+func (q *Query) Tag(tags map[string]string) *Query {
+	if len(tags) == 0 {
+		return q
+	}
+	if q.tags == nil {
+		q.tags = make(map[string]string, len(tags))
+	}
+	for k, v := range tags {
+		q.tags[k] = v
+	}
+	return q
+}
+
+// TagContext adds the tags that were extracted from the context by `ContextTags`, it does nothing if `ContextTags` was not set.
+func (q *Query) TagContext(ctx context.Context) *Query {
+	if ContextTags == nil || ctx == nil {
+		return q
+	}
+	return q.Tag(ContextTags(ctx))
+}
+
+// buildComment builds the comments and the tags that will be placed at the end of the query.
+func (q *Query) buildComment() string {
+	var parts []string
+	for _, v := range q.comments {
+		parts = append(parts, fmt.Sprintf("/* %s */", escapeComment(v)))
+	}
+	if len(q.tags) != 0 {
+		keys := make([]string, 0, len(q.tags))
+		for k := range q.tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = fmt.Sprintf("%s='%s'", encodeTag(k), encodeTag(q.tags[k]))
+		}
+		parts = append(parts, fmt.Sprintf("/*%s*/", strings.Join(pairs, ",")))
+	}
+	return strings.Join(parts, " ")
+}
+
+// escapeComment breaks the comment delimiters (`/*`, `*/`) so the comment can't be closed early or nested,
+// and encodes the question marks as `%3F` like the tags, so the drivers that interpolate the parameters
+// on the client side won't take them as the placeholders.
+func escapeComment(comment string) string {
+	r := strings.NewReplacer("/*", "/ *", "*/", "* /")
+	// The overlapped delimiters (e.g. `/*/`) leave another delimiter after the replacement, replace until there's none.
+	for strings.Contains(comment, "/*") || strings.Contains(comment, "*/") {
+		comment = r.Replace(comment)
+	}
+	return strings.ReplaceAll(comment, "?", "%3F")
+}
+
+// encodeTag URL-encodes the key or the value of the tag as the sqlcommenter specification,
+// the quotes, the asterisks and the slashes are all encoded so they can't break the comment.
+func encodeTag(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
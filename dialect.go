@@ -0,0 +1,59 @@
+package rushia
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect is the SQL dialect of the database, it decides how the dialect-specific options will be built.
+type Dialect int
+
+const (
+	// DialectMySQL is the dialect for MySQL and MariaDB, it's the default dialect.
+	DialectMySQL Dialect = iota
+	// DialectPostgreSQL is the dialect for PostgreSQL.
+	DialectPostgreSQL
+)
+
+// SetDialect sets the SQL dialect of the query, the default dialect is MySQL.
+// The identifiers are quoted with the double quotes, the placeholders are numbered (e.g. `$1`),
+// and the `LIMIT` is built with `OFFSET` in PostgreSQL. The sub queries are built with the dialect of the outer query.
+func (q *Query) SetDialect(d Dialect) *Query {
+	q.dialect = d
+	return q
+}
+
+// formatQuery converts the identifiers and the placeholders of the built query into the forms of the dialect.
+func formatQuery(query string, d Dialect) string {
+	return numberPlaceholders(quoteIdents(query, d), d)
+}
+
+// numberPlaceholders converts the `?` placeholders into the numbered placeholders (e.g. `$1`, `$2`) in PostgreSQL,
+// the `?` symbols in the quoted strings, the comments and the JSON operators are left as-is.
+func numberPlaceholders(query string, d Dialect) string {
+	if d != DialectPostgreSQL {
+		return query
+	}
+	tokens := filterTokens(lex(query, d), tokenTypePlaceholder)
+	replacements := make([]string, len(tokens))
+	for i := range tokens {
+		replacements[i] = fmt.Sprintf("$%d", i+1)
+	}
+	return replaceTokens(query, tokens, replacements)
+}
+
+// quoteIdents converts the backtick-quoted identifiers in the query into the quoted identifiers of the dialect
+// (e.g. `Users` becomes "Users" in PostgreSQL), the quoted strings and the comments are left as-is.
+func quoteIdents(query string, d Dialect) string {
+	if d != DialectPostgreSQL {
+		return query
+	}
+	tokens := filterTokens(lex(query, d), tokenTypeIdent)
+	replacements := make([]string, len(tokens))
+	for i, t := range tokens {
+		ident := strings.TrimSuffix(query[t.start+1:t.end], "`")
+		ident = strings.ReplaceAll(ident, "``", "`")
+		replacements[i] = fmt.Sprintf(`"%s"`, strings.ReplaceAll(ident, `"`, `""`))
+	}
+	return replaceTokens(query, tokens, replacements)
+}
@@ -0,0 +1,72 @@
+package rushia
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Encoder converts a value of the custom type to a value that the database driver accepts (e.g. a UUID to a string),
+// it's applied to the bound parameters while building.
+type Encoder func(v interface{}) (interface{}, error)
+
+var (
+	encodersMutex sync.RWMutex
+	encoders      = make(map[reflect.Type]Encoder)
+
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// RegisterEncoder registers an encoder for the type of the example value (e.g. `uuid.UUID{}`, `time.Time{}`),
+// the encoder replaces the previous one of the same type.
+func RegisterEncoder(example interface{}, e Encoder) {
+	encodersMutex.Lock()
+	defer encodersMutex.Unlock()
+	encoders[reflect.TypeOf(example)] = e
+}
+
+// ClearEncoders removes all the registered encoders.
+func ClearEncoders() {
+	encodersMutex.Lock()
+	defer encodersMutex.Unlock()
+	encoders = make(map[reflect.Type]Encoder)
+}
+
+// encoderOf returns the registered encoder of the type.
+func encoderOf(t reflect.Type) (Encoder, bool) {
+	encodersMutex.RLock()
+	defer encodersMutex.RUnlock()
+	e, ok := encoders[t]
+	return e, ok
+}
+
+// encodeValue converts the value with the registered encoder of its type, the value is returned as-is if there's no encoder.
+func encodeValue(v interface{}) interface{} {
+	if v == nil {
+		return v
+	}
+	e, ok := encoderOf(reflect.TypeOf(v))
+	if !ok {
+		return v
+	}
+	value, err := e(v)
+	if err != nil {
+		panic(fmt.Errorf("rushia: failed to encode the value of %T: %w", v, err))
+	}
+	return value
+}
+
+// isList returns true if the value is a list that should be expanded to multiple placeholders (e.g. `IN (?, ?)`),
+// the byte slices (e.g. `json.RawMessage`, `net.IP`), the `driver.Valuer` implementations and the types with a registered encoder are single values.
+func isList(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() != reflect.Slice || t.Elem().Kind() == reflect.Uint8 || t.Implements(valuerType) {
+		return false
+	}
+	_, ok := encoderOf(t)
+	return !ok
+}
@@ -0,0 +1,179 @@
+package rushia
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedExplain is returned when the query or the explain format was not supported by `Explain`.
+	ErrUnsupportedExplain = errors.New("rushia: unsupported explain")
+)
+
+// ExplainOutput is the output format of the execution plan.
+type ExplainOutput int
+
+const (
+	// ExplainTraditional outputs the plan in the default format of the dialect (the table rows of MySQL, the text of PostgreSQL).
+	ExplainTraditional ExplainOutput = iota
+	// ExplainJSON outputs the plan in JSON (`FORMAT=JSON`, `FORMAT JSON`).
+	ExplainJSON
+	// ExplainTree outputs the plan in the tree format, it's MySQL only (`FORMAT=TREE`).
+	ExplainTree
+)
+
+// ExplainFormat is the options of `Explain`.
+type ExplainFormat struct {
+	// Output is the output format of the plan.
+	Output ExplainOutput
+	// Analyze executes the query and outputs the actual costs (`EXPLAIN ANALYZE`),
+	// be careful that the `UPDATE`, `DELETE` queries will be executed as well. It can't be used with `ExplainJSON` in MySQL.
+	Analyze bool
+}
+
+// Explain wraps the query in the `EXPLAIN` statement of the dialect with the same parameters,
+// it works with the `SELECT`, `INSERT`, `UPDATE` and `DELETE` queries, otherwise `ErrUnsupportedExplain` will be returned while building.
+func (q *Query) Explain(format ExplainFormat) *Query {
+	q.explain = &format
+	return q
+}
+
+// checkExplain returns ErrUnsupportedExplain if the query or the dialect doesn't support the explain format.
+func (q *Query) checkExplain() error {
+	if q.explain == nil {
+		return nil
+	}
+	switch q.typ {
+	case QueryTypeSelect, QueryTypeExists, QueryTypeInsert, QueryTypeReplace, QueryTypeInsertSelect, QueryTypeUpdate, QueryTypePatch, QueryTypeDelete:
+	default:
+		return fmt.Errorf("%w: %s query", ErrUnsupportedExplain, q.typ)
+	}
+	if q.dialect == DialectPostgreSQL && q.explain.Output == ExplainTree {
+		return fmt.Errorf("%w: the tree format is not supported by PostgreSQL", ErrUnsupportedExplain)
+	}
+	// `EXPLAIN ANALYZE` outputs the tree format only before MySQL 8.3.
+	if q.dialect == DialectMySQL && q.explain.Analyze && q.explain.Output == ExplainJSON {
+		return fmt.Errorf("%w: the JSON format of EXPLAIN ANALYZE is not supported by MySQL", ErrUnsupportedExplain)
+	}
+	return nil
+}
+
+// buildExplain builds the `EXPLAIN` statement that will be placed before the query.
+func (q *Query) buildExplain() string {
+	if q.explain == nil {
+		return ""
+	}
+	if q.dialect == DialectPostgreSQL {
+		var options []string
+		if q.explain.Analyze {
+			options = append(options, "ANALYZE")
+		}
+		if q.explain.Output == ExplainJSON {
+			options = append(options, "FORMAT JSON")
+		}
+		if len(options) == 0 {
+			return "EXPLAIN"
+		}
+		return fmt.Sprintf("EXPLAIN (%s)", strings.Join(options, ", "))
+	}
+	qu := "EXPLAIN"
+	if q.explain.Analyze {
+		qu += " ANALYZE"
+	}
+	switch q.explain.Output {
+	case ExplainJSON:
+		qu += " FORMAT=JSON"
+	case ExplainTree:
+		qu += " FORMAT=TREE"
+	}
+	return qu
+}
+
+// PlanTable is a table access of an execution plan.
+type PlanTable struct {
+	// Table is the table name (or the alias) that was accessed.
+	Table string
+	// Access is the access type of MySQL (e.g. `ALL`, `ref`) or the node type of PostgreSQL (e.g. `Seq Scan`).
+	Access string
+	// FullScan is true if the table was fully scanned.
+	FullScan bool
+}
+
+var (
+	// planScanRegexp matches the full table scans of the MySQL tree plan and the PostgreSQL text plan.
+	planScanRegexp = regexp.MustCompile(`(?:Table scan|Seq Scan) on ([^\s(]+)`)
+)
+
+// ParsePlan parses the recorded output of `Explain` (e.g. a plan fixture of the tests) into the table accesses.
+// The JSON plans of MySQL and PostgreSQL are fully parsed, the text plans (the MySQL tree, the PostgreSQL text) report the full table scans only.
+func ParsePlan(plan []byte) ([]PlanTable, error) {
+	plan = bytes.TrimSpace(plan)
+	if len(plan) == 0 || (plan[0] != '{' && plan[0] != '[') {
+		var tables []PlanTable
+		for _, v := range planScanRegexp.FindAllSubmatch(plan, -1) {
+			tables = append(tables, PlanTable{Table: trimIdent(string(v[1])), Access: "ALL", FullScan: true})
+		}
+		return tables, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(plan, &v); err != nil {
+		return nil, fmt.Errorf("rushia: failed to parse the plan: %w", err)
+	}
+	var tables []PlanTable
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch j := v.(type) {
+		case map[string]interface{}:
+			// MySQL: {"table": {"table_name": "Users", "access_type": "ALL"}}
+			if name, ok := j["table_name"].(string); ok {
+				access, _ := j["access_type"].(string)
+				tables = append(tables, PlanTable{Table: name, Access: access, FullScan: access == "ALL" || access == "table"})
+			}
+			// PostgreSQL: {"Node Type": "Seq Scan", "Relation Name": "users"}
+			if name, ok := j["Relation Name"].(string); ok {
+				access, _ := j["Node Type"].(string)
+				tables = append(tables, PlanTable{Table: name, Access: access, FullScan: access == "Seq Scan"})
+			}
+			// Walk the keys in order so the order of the tables is stable.
+			keys := make([]string, 0, len(j))
+			for k := range j {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(j[k])
+			}
+		case []interface{}:
+			for _, w := range j {
+				walk(w)
+			}
+		}
+	}
+	walk(v)
+	return tables, nil
+}
+
+// HasFullTableScan returns true if the table was fully scanned in the recorded plan,
+// it's useful to assert the queries are using the indexes in the tests with the plan fixtures.
+func HasFullTableScan(plan []byte, table string) (bool, error) {
+	tables, err := ParsePlan(plan)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range tables {
+		if v.FullScan && strings.EqualFold(v.Table, table) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// trimIdent removes the quotes of the identifier.
+func trimIdent(ident string) string {
+	return strings.Trim(ident, "`\"")
+}
@@ -0,0 +1,196 @@
+package rushia
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	expressionTypeFunc expressionType = iota
+	expressionTypeOperator
+	expressionTypeCast
+	expressionTypeCase
+)
+
+type expressionType int
+
+// Expression is a composable SQL expression (e.g. `CASE WHEN`, `COALESCE`, arithmetic) that is able to be used
+// anywhere a value is accepted, such as the `Insert` and `Update` data, `Select`, `Where` and `OrderBy`.
+//
+// The operands are treated as the values and will be bound as the parameters, use `Ident` or `Col` for the columns,
+// and the nested expressions or sub queries will be built in place.
+type Expression struct {
+	typ      expressionType
+	name     string
+	operands []interface{}
+
+	// subject is the value to compare with in a simple `CASE` expression.
+	subject    interface{}
+	hasSubject bool
+	whens      []when
+	els        interface{}
+	hasElse    bool
+
+	alias string
+}
+
+// when is a `WHEN ... THEN ...` branch of the `CASE` expression.
+type when struct {
+	condition interface{}
+	value     interface{}
+}
+
+// Func creates a function call expression (e.g. `Func("DATE_FORMAT", Ident("CreatedAt"), "%Y-%m")`),
+// the function name will be used as-is so it must not contain any user input.
+func Func(name string, args ...interface{}) *Expression {
+	return &Expression{
+		typ:      expressionTypeFunc,
+		name:     name,
+		operands: args,
+	}
+}
+
+// Coalesce creates a `COALESCE` expression that returns the first non-NULL value.
+func Coalesce(values ...interface{}) *Expression {
+	return Func("COALESCE", values...)
+}
+
+// Concat creates a `CONCAT` expression that concatenates the values.
+func Concat(values ...interface{}) *Expression {
+	return Func("CONCAT", values...)
+}
+
+// Cast creates a `CAST(value AS type)` expression, the type will be used as-is (e.g. `DECIMAL(10,2)`).
+func Cast(value interface{}, typ string) *Expression {
+	return &Expression{
+		typ:      expressionTypeCast,
+		name:     typ,
+		operands: []interface{}{value},
+	}
+}
+
+// Add creates an `a + b` expression, it will be wrapped in the parentheses.
+func Add(a, b interface{}) *Expression {
+	return newOperator("+", a, b)
+}
+
+// Sub creates an `a - b` expression, it will be wrapped in the parentheses.
+func Sub(a, b interface{}) *Expression {
+	return newOperator("-", a, b)
+}
+
+// Mul creates an `a * b` expression, it will be wrapped in the parentheses.
+func Mul(a, b interface{}) *Expression {
+	return newOperator("*", a, b)
+}
+
+// newOperator creates an arithmetic expression with the operator.
+func newOperator(operator string, a, b interface{}) *Expression {
+	return &Expression{
+		typ:      expressionTypeOperator,
+		name:     operator,
+		operands: []interface{}{a, b},
+	}
+}
+
+// Case creates a `CASE` expression, pass a subject to create a simple `CASE subject WHEN value THEN ...` expression.
+func Case(subject ...interface{}) *Expression {
+	e := &Expression{
+		typ: expressionTypeCase,
+	}
+	if len(subject) != 0 {
+		e.subject = subject[0]
+		e.hasSubject = true
+	}
+	return e
+}
+
+// copy creates a copy of the expression, so the original one won't be modified.
+func (e *Expression) copy() *Expression {
+	b := *e
+	b.operands = append([]interface{}{}, e.operands...)
+	b.whens = append([]when{}, e.whens...)
+	return &b
+}
+
+// When adds a `WHEN ... THEN ...` branch to the `CASE` expression.
+// The condition is a raw SQL condition string or an `*Expr` (e.g. `NewExpr("Age > ?", 18)`),
+// or it's a value to compare with if the `CASE` expression has a subject.
+func (e *Expression) When(condition interface{}, value interface{}) *Expression {
+	b := e.copy()
+	b.whens = append(b.whens, when{
+		condition: condition,
+		value:     value,
+	})
+	return b
+}
+
+// Else sets the `ELSE` value of the `CASE` expression.
+func (e *Expression) Else(value interface{}) *Expression {
+	b := e.copy()
+	b.els = value
+	b.hasElse = true
+	return b
+}
+
+// As gives the expression an alias, it's only rendered in the selected columns.
+func (e *Expression) As(alias string) *Expression {
+	b := e.copy()
+	b.alias = alias
+	return b
+}
+
+// values returns the operands and the values of the branches, so the nested sub queries could be found.
+func (e *Expression) values() []interface{} {
+	values := append([]interface{}{}, e.operands...)
+	if e.hasSubject {
+		values = append(values, e.subject)
+	}
+	for _, v := range e.whens {
+		values = append(values, v.condition, v.value)
+	}
+	if e.hasElse {
+		values = append(values, e.els)
+	}
+	return values
+}
+
+// buildExpression builds the expression and binds the parameters to the query in order,
+// the alias will be appended if `withAlias` is true.
+func (q *Query) buildExpression(e *Expression, withAlias bool) string {
+	var query string
+	switch e.typ {
+	case expressionTypeFunc:
+		query = fmt.Sprintf("%s(%s)", e.name, q.bindParams(e.operands, nil))
+	case expressionTypeCast:
+		query = fmt.Sprintf("CAST(%s AS %s)", q.bindParams(e.operands, nil), e.name)
+	case expressionTypeOperator:
+		query = fmt.Sprintf("(%s %s %s)", q.bindParam(e.operands[0], nil), e.name, q.bindParam(e.operands[1], nil))
+	case expressionTypeCase:
+		if len(e.whens) == 0 {
+			panic("rushia: the CASE expression requires at least one WHEN")
+		}
+		var b strings.Builder
+		b.WriteString("CASE")
+		if e.hasSubject {
+			b.WriteString(fmt.Sprintf(" %s", q.bindParam(e.subject, nil)))
+		}
+		for _, v := range e.whens {
+			condition, ok := v.condition.(string)
+			// The raw condition string is only allowed in the searched `CASE` expression.
+			if !ok || e.hasSubject {
+				condition = q.bindParam(v.condition, nil)
+			}
+			b.WriteString(fmt.Sprintf(" WHEN %s THEN %s", condition, q.bindParam(v.value, nil)))
+		}
+		if e.hasElse {
+			b.WriteString(fmt.Sprintf(" ELSE %s", q.bindParam(e.els, nil)))
+		}
+		b.WriteString(" END")
+		query = b.String()
+	}
+	if withAlias && e.alias != "" {
+		query += fmt.Sprintf(" AS %s", quoteIdent(e.alias))
+	}
+	return query
+}
@@ -0,0 +1,221 @@
+package rushia
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrUnknownField is returned when a field was not in the `Sortable` or `Filterable` allow-list.
+	ErrUnknownField = errors.New("rushia: unknown field")
+	// ErrUnsupportedOperator is returned when a filter operator was unknown or not allowed for the field.
+	ErrUnsupportedOperator = errors.New("rushia: unsupported filter operator")
+)
+
+// Sortable maps the external field names to the allowed columns, so the user-driven sorting is safe to be applied.
+type Sortable map[string]string
+
+// Apply parses the comma separated sorting fields (e.g. `-created,name`) and appends the `ORDER BY` option to the query,
+// the field is sorted in descending order if it was prefixed with `-`. Returns ErrUnknownField if a field was not allowed.
+func (s Sortable) Apply(q *Query, fields string) (*Query, error) {
+	var orders []order
+	for _, v := range strings.Split(fields, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		direction := OrderAsc
+		switch v[0] {
+		case '-':
+			direction = OrderDesc
+			v = v[1:]
+		case '+':
+			v = v[1:]
+		}
+		column, ok := s[v]
+		if !ok {
+			return q, fmt.Errorf("%w: %s", ErrUnknownField, v)
+		}
+		orders = append(orders, order{column: Ident(column), direction: direction})
+	}
+	q.orders = append(q.orders, orders...)
+	return q, nil
+}
+
+// FilterOperator is the operator of a filter.
+type FilterOperator string
+
+const (
+	// FilterEq filters the field that equals to the value (`= ?`), it's the default operator.
+	FilterEq FilterOperator = "eq"
+	// FilterNe filters the field that doesn't equal to the value (`<> ?`).
+	FilterNe FilterOperator = "ne"
+	// FilterGt filters the field that is greater than the value (`> ?`).
+	FilterGt FilterOperator = "gt"
+	// FilterGte filters the field that is greater than or equals to the value (`>= ?`).
+	FilterGte FilterOperator = "gte"
+	// FilterLt filters the field that is less than the value (`< ?`).
+	FilterLt FilterOperator = "lt"
+	// FilterLte filters the field that is less than or equals to the value (`<= ?`).
+	FilterLte FilterOperator = "lte"
+	// FilterIn filters the field that is one of the comma separated values (`IN (?, ?)`).
+	FilterIn FilterOperator = "in"
+	// FilterLike filters the field that contains the value (`LIKE ?`), the wildcards in the value will be escaped.
+	FilterLike FilterOperator = "like"
+)
+
+// toQuery returns the condition of the operator.
+func (o FilterOperator) toQuery() (string, bool) {
+	switch o {
+	case FilterEq:
+		return "?? = ?", true
+	case FilterNe:
+		return "?? <> ?", true
+	case FilterGt:
+		return "?? > ?", true
+	case FilterGte:
+		return "?? >= ?", true
+	case FilterLt:
+		return "?? < ?", true
+	case FilterLte:
+		return "?? <= ?", true
+	case FilterIn:
+		return "?? IN ?", true
+	case FilterLike:
+		return "?? LIKE ?", true
+	}
+	return "", false
+}
+
+// FilterField is the allowed column and the operators of a filter field.
+type FilterField struct {
+	// Column is the column name of the field.
+	Column string
+	// Operators is the allowed operators, every operator is allowed if it's empty.
+	Operators []FilterOperator
+}
+
+// isAllowed returns true if the operator is allowed for the field.
+func (f FilterField) isAllowed(o FilterOperator) bool {
+	if len(f.Operators) == 0 {
+		return true
+	}
+	for _, v := range f.Operators {
+		if v == o {
+			return true
+		}
+	}
+	return false
+}
+
+// Filterable maps the external field names to the allowed columns and the operators, so the user-driven filtering is safe to be applied.
+type Filterable map[string]FilterField
+
+// Apply parses the filters (e.g. `url.Values`) and appends the `WHERE` conditions to the query.
+// The key is the field name with an optional operator (e.g. `name`, `age[gte]`, `status[in]`),
+// the `eq` operator will be used if there's no operator. Returns ErrUnknownField or ErrUnsupportedOperator if the filter was not allowed,
+// the query won't be modified if any of the filters was not allowed.
+func (f Filterable) Apply(q *Query, filters map[string][]string) (*Query, error) {
+	keys := make([]string, 0, len(filters))
+	for k := range filters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	type filter struct {
+		query    string
+		operator FilterOperator
+		column   string
+		value    interface{}
+	}
+	var result []filter
+	for _, k := range keys {
+		name, operator := k, FilterEq
+		if i := strings.Index(k, "["); i != -1 && strings.HasSuffix(k, "]") {
+			name, operator = k[:i], FilterOperator(k[i+1:len(k)-1])
+		}
+		field, ok := f[name]
+		if !ok {
+			return q, fmt.Errorf("%w: %s", ErrUnknownField, name)
+		}
+		query, ok := operator.toQuery()
+		if !ok || !field.isAllowed(operator) {
+			return q, fmt.Errorf("%w: %s[%s]", ErrUnsupportedOperator, name, operator)
+		}
+		values := filters[k]
+		if len(values) == 0 {
+			continue
+		}
+		if operator == FilterIn {
+			var list []string
+			for _, v := range values {
+				list = append(list, strings.Split(v, ",")...)
+			}
+			result = append(result, filter{query, operator, field.Column, list})
+			continue
+		}
+		result = append(result, filter{query, operator, field.Column, values[0]})
+	}
+	// Apply the filters after all of them were validated, so the query stays untouched on the errors.
+	for _, v := range result {
+		q.putFilter(v.query, v.operator, v.column, v.value)
+	}
+	return q, nil
+}
+
+// WhereStruct converts the fields of the filter struct into the `WHERE` conditions, the zero value fields are skipped as `Patch` does.
+// The column and the operator are specified by the tag (e.g. `rushia:"created_at,op=gte"`), the operator is `eq` by default.
+// See `FilterOperator` for the available operators, the `in` operator expects a slice.
+func (q *Query) WhereStruct(filter interface{}) *Query {
+	v := reflect.Indirect(reflect.ValueOf(filter))
+	if v.Kind() != reflect.Struct {
+		q.setErr(fmt.Errorf("rushia: WhereStruct requires a struct, got %T", filter))
+		return q
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		column, options, ok := parseFieldTag(t.Field(i))
+		if !ok {
+			continue
+		}
+		operator := FilterEq
+		for _, o := range options {
+			if strings.HasPrefix(o, "op=") {
+				operator = FilterOperator(strings.TrimPrefix(o, "op="))
+			}
+		}
+		query, ok := operator.toQuery()
+		if !ok {
+			q.setErr(fmt.Errorf("%w: %s[%s]", ErrUnsupportedOperator, column, operator))
+			return q
+		}
+		value := v.Field(i)
+		if value.IsZero() || (value.Kind() == reflect.Slice && value.Len() == 0) {
+			continue
+		}
+		if value.Kind() == reflect.Ptr {
+			value = value.Elem()
+		}
+		q.putFilter(query, operator, column, value.Interface())
+	}
+	return q
+}
+
+// putFilter creates the `WHERE` condition of the filter operator, the value will be wrapped with `%` for the `like` operator.
+func (q *Query) putFilter(query string, operator FilterOperator, column string, value interface{}) {
+	if operator == FilterLike {
+		value = fmt.Sprintf("%%%s%%", escapeLike(fmt.Sprint(value)))
+	}
+	q.Where(query, Ident(column), value)
+}
+
+// escapeLike escapes the wildcards of the `LIKE` condition in the value.
+func escapeLike(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(v)
+}
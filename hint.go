@@ -0,0 +1,180 @@
+package rushia
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedIndexHint is returned when the index hints were used in a dialect other than MySQL,
+	// or in a query that is not a `SELECT` or `UPDATE` query.
+	ErrUnsupportedIndexHint = errors.New("rushia: unsupported index hint")
+)
+
+const (
+	indexHintTypeUse indexHintType = iota
+	indexHintTypeForce
+	indexHintTypeIgnore
+)
+
+type indexHintType int
+
+func (t indexHintType) toQuery() string {
+	switch t {
+	case indexHintTypeForce:
+		return "FORCE INDEX"
+	case indexHintTypeIgnore:
+		return "IGNORE INDEX"
+	default:
+		return "USE INDEX"
+	}
+}
+
+// IndexScope limits the index hint to the joining, the sorting or the grouping.
+type IndexScope int
+
+const (
+	// IndexForAll applies the index hint to every operation, it's the default scope.
+	IndexForAll IndexScope = iota
+	// IndexForJoin applies the index hint to the joining only (`FOR JOIN`).
+	IndexForJoin
+	// IndexForOrderBy applies the index hint to the sorting only (`FOR ORDER BY`).
+	IndexForOrderBy
+	// IndexForGroupBy applies the index hint to the grouping only (`FOR GROUP BY`).
+	IndexForGroupBy
+)
+
+func (s IndexScope) toQuery() string {
+	switch s {
+	case IndexForJoin:
+		return "FOR JOIN"
+	case IndexForOrderBy:
+		return "FOR ORDER BY"
+	case IndexForGroupBy:
+		return "FOR GROUP BY"
+	default:
+		return ""
+	}
+}
+
+// IndexHint is a MySQL index hint (e.g. `USE INDEX (idx_name)`) of a table, create it with `UseIndex`, `ForceIndex` or `IgnoreIndex`.
+type IndexHint struct {
+	typ     indexHintType
+	scope   IndexScope
+	indexes []string
+}
+
+// UseIndex creates a `USE INDEX` hint.
+func UseIndex(indexes ...string) IndexHint {
+	return IndexHint{typ: indexHintTypeUse, indexes: indexes}
+}
+
+// ForceIndex creates a `FORCE INDEX` hint.
+func ForceIndex(indexes ...string) IndexHint {
+	return IndexHint{typ: indexHintTypeForce, indexes: indexes}
+}
+
+// IgnoreIndex creates an `IGNORE INDEX` hint.
+func IgnoreIndex(indexes ...string) IndexHint {
+	return IndexHint{typ: indexHintTypeIgnore, indexes: indexes}
+}
+
+// For limits the index hint to the joining, the sorting or the grouping.
+func (h IndexHint) For(scope IndexScope) IndexHint {
+	h.scope = scope
+	return h
+}
+
+// toQuery converts the index hint into the SQL (e.g. `FORCE INDEX FOR ORDER BY (`idx_a`)`).
+func (h IndexHint) toQuery() string {
+	indexes := make([]string, len(h.indexes))
+	for i, v := range h.indexes {
+		indexes[i] = quoteIdent(v)
+	}
+	qu := h.typ.toQuery()
+	if scope := h.scope.toQuery(); scope != "" {
+		qu += fmt.Sprintf(" %s", scope)
+	}
+	return fmt.Sprintf("%s (%s)", qu, strings.Join(indexes, ", "))
+}
+
+// UseIndex adds a `USE INDEX` hint to the table of the query, see `IndexHint` to limit the scope.
+func (q *Query) UseIndex(indexes ...string) *Query {
+	return q.IndexHint(UseIndex(indexes...))
+}
+
+// ForceIndex adds a `FORCE INDEX` hint to the table of the query, see `IndexHint` to limit the scope.
+func (q *Query) ForceIndex(indexes ...string) *Query {
+	return q.IndexHint(ForceIndex(indexes...))
+}
+
+// IgnoreIndex adds an `IGNORE INDEX` hint to the table of the query, see `IndexHint` to limit the scope.
+func (q *Query) IgnoreIndex(indexes ...string) *Query {
+	return q.IndexHint(IgnoreIndex(indexes...))
+}
+
+// IndexHint adds the index hints to the table of the query (e.g. `IndexHint(ForceIndex("idx_created").For(IndexForOrderBy))`).
+// The index hints are MySQL only and can be used in the `SELECT` and `UPDATE` queries, otherwise `ErrUnsupportedIndexHint` will be returned while building.
+func (q *Query) IndexHint(hints ...IndexHint) *Query {
+	q.indexHints = append(q.indexHints, hints...)
+	return q
+}
+
+// JoinIndexHint adds the index hints to the latest joined table.
+func (q *Query) JoinIndexHint(hints ...IndexHint) *Query {
+	q.joins[len(q.joins)-1].indexHints = append(q.joins[len(q.joins)-1].indexHints, hints...)
+	return q
+}
+
+// OptimizerHint adds the optimizer hints (e.g. `MAX_EXECUTION_TIME(1000)`, `BKA(t1)`) to the query,
+// they will be placed in a `/*+ ... */` comment right after the `SELECT`, `INSERT`, `UPDATE` or `DELETE` keyword.
+func (q *Query) OptimizerHint(hints ...string) *Query {
+	for _, v := range hints {
+		if strings.Contains(v, "*/") {
+			q.setErr(fmt.Errorf("rushia: the optimizer hint must not contain the end of the comment: %s", v))
+			return q
+		}
+	}
+	q.optimizerHints = append(q.optimizerHints, hints...)
+	return q
+}
+
+// checkIndexHints returns ErrUnsupportedIndexHint if the index hints can't be used in the query or the dialect.
+func (q *Query) checkIndexHints() error {
+	hasHints := len(q.indexHints) != 0
+	for _, v := range q.joins {
+		hasHints = hasHints || len(v.indexHints) != 0
+	}
+	if !hasHints {
+		return nil
+	}
+	if q.dialect != DialectMySQL {
+		return fmt.Errorf("%w: the index hints are only supported by MySQL", ErrUnsupportedIndexHint)
+	}
+	switch q.typ {
+	case QueryTypeSelect, QueryTypeExists, QueryTypeUpdate, QueryTypePatch:
+		return nil
+	}
+	return fmt.Errorf("%w: the index hints can't be used in a %s query", ErrUnsupportedIndexHint, q.typ)
+}
+
+// buildIndexHints builds the index hints that will be placed after the table name.
+func (q *Query) buildIndexHints(hints []IndexHint) string {
+	if len(hints) == 0 {
+		return ""
+	}
+	parts := make([]string, len(hints))
+	for i, v := range hints {
+		parts[i] = v.toQuery()
+	}
+	return fmt.Sprintf(" %s", strings.Join(parts, " "))
+}
+
+// buildOptimizerHints builds the optimizer hint comment that will be placed after the statement keyword.
+func (q *Query) buildOptimizerHints() string {
+	if len(q.optimizerHints) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("/*+ %s */", strings.Join(q.optimizerHints, " "))
+}
@@ -0,0 +1,112 @@
+package rushia
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StrictIdentifiers treats the plain strings as the identifiers only while they are being used as the column or the table names,
+// so they will always be quoted. Use `Raw` for the expressions (e.g. `COUNT(*)`, `ID ASC`) if it was enabled.
+//
+// Without the strict mode, a string that contains a dot, a space or a parenthesis won't be quoted.
+var StrictIdentifiers = false
+
+// Ident is an identifier such as a column or a table name,
+// each part separated by the dot will be quoted (e.g. `schema.table.column` becomes `schema`.`table`.`column`).
+type Ident string
+
+// Raw is a raw SQL fragment that will be used as-is, make sure it contains no user input.
+type Raw string
+
+// Column is an identifier of a column or a table that is able to be aliased.
+type Column struct {
+	name  Ident
+	alias string
+}
+
+// Col creates an identifier of a column or a table, call `As` to give it an alias.
+func Col(name string) *Column {
+	return &Column{
+		name: Ident(name),
+	}
+}
+
+// As gives the column or the table an alias.
+func (c *Column) As(alias string) *Column {
+	return &Column{
+		name:  c.name,
+		alias: alias,
+	}
+}
+
+// toQuery converts the column into the quoted identifier with the alias.
+func (c *Column) toQuery() string {
+	if c.alias == "" {
+		return quoteIdent(string(c.name))
+	}
+	return fmt.Sprintf("%s AS %s", quoteIdent(string(c.name)), quoteIdent(c.alias))
+}
+
+// quoteIdent quotes each part of the identifier that separated by the dot with the backticks,
+// and the backticks in the identifier will be doubled. The `*` part won't be quoted (e.g. `Users`.*).
+func quoteIdent(v string) string {
+	parts := strings.Split(v, ".")
+	for i, p := range parts {
+		if p == "*" {
+			continue
+		}
+		parts[i] = fmt.Sprintf("`%s`", strings.ReplaceAll(p, "`", "``"))
+	}
+	return strings.Join(parts, ".")
+}
+
+// unquoteIdent removes the quotes of each part of the identifier (e.g. `schema`.`table` becomes schema.table).
+func unquoteIdent(v string) string {
+	parts := strings.Split(v, ".")
+	for i, p := range parts {
+		switch {
+		case len(p) >= 2 && p[0] == '`' && p[len(p)-1] == '`':
+			parts[i] = strings.ReplaceAll(p[1:len(p)-1], "``", "`")
+		case len(p) >= 2 && p[0] == '"' && p[len(p)-1] == '"':
+			parts[i] = strings.ReplaceAll(p[1:len(p)-1], `""`, `"`)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// parseTable resolves the table name and the alias from a table (e.g. `shop.Orders`, `Orders AS o`, `Ident`, `Col("Orders").As("o")`),
+// the name is empty if the table was not a named table (e.g. a sub query).
+func parseTable(table interface{}) (name string, alias string) {
+	switch v := table.(type) {
+	case string:
+		fields := strings.Fields(v)
+		switch {
+		case len(fields) == 1:
+			name = fields[0]
+		case len(fields) == 2:
+			name, alias = fields[0], fields[1]
+		case len(fields) == 3 && strings.EqualFold(fields[1], "AS"):
+			name, alias = fields[0], fields[2]
+		default:
+			return "", ""
+		}
+	case Ident:
+		name = string(v)
+	case *Column:
+		name, alias = string(v.name), v.alias
+	case *Query:
+		if v != nil {
+			alias = v.alias
+		}
+	}
+	return unquoteIdent(name), unquoteIdent(alias)
+}
+
+// tableRef returns the name that refers to the table in the conditions, it's the alias if the table was aliased.
+func tableRef(table interface{}) string {
+	name, alias := parseTable(table)
+	if alias != "" {
+		return alias
+	}
+	return name
+}
@@ -0,0 +1,228 @@
+package rushia
+
+// Condition is a read-only representation of a `WHERE`, `HAVING` or joining condition.
+type Condition struct {
+	// Query is the condition query, the escaped `??` values were already replaced.
+	Query string
+	// Args is the arguments of the condition.
+	Args []interface{}
+	// Or is true if the condition was connected with `OR`.
+	Or bool
+	// Group is the nested conditions that were wrapped in the parentheses, `Query` and `Args` are empty if it was set.
+	Group []Condition
+	// TupleColumns is the columns of the row-value `IN` condition that was created by `WhereIn`, the tuples are the only argument in `Args`.
+	TupleColumns []string
+}
+
+// Join is a read-only representation of a table join.
+type Join struct {
+	// Type is the join type (e.g. `LEFT JOIN`).
+	Type string
+	// Table is the table name, or a *Query if it was a sub query.
+	Table interface{}
+	// Conditions is the joining conditions.
+	Conditions []Condition
+}
+
+// Table returns the table of the query, it's a table name or a *Query if it was a sub query.
+func (q *Query) Table() interface{} {
+	return q.table
+}
+
+// TableRef returns the name that refers to the table in the conditions, it's the alias if the table was aliased (e.g. `o` of `Orders AS o`).
+// It's useful for the scopes to qualify the columns.
+func (q *Query) TableRef() string {
+	return tableRef(q.table)
+}
+
+// Alias returns the alias of the query.
+func (q *Query) Alias() string {
+	return q.alias
+}
+
+// Conditions returns the `WHERE` conditions of the query.
+func (q *Query) Conditions() []Condition {
+	return exportConditions(q.wheres)
+}
+
+// HavingConditions returns the `HAVING` conditions of the query.
+func (q *Query) HavingConditions() []Condition {
+	return exportConditions(q.havings)
+}
+
+// SelectedColumns returns the columns of the `SELECT` query, it's empty if it selects everything.
+func (q *Query) SelectedColumns() []interface{} {
+	return append([]interface{}{}, q.selects...)
+}
+
+// Joins returns the table joins of the query.
+func (q *Query) Joins() []Join {
+	joins := make([]Join, len(q.joins))
+	for i, v := range q.joins {
+		joins[i] = Join{
+			Type:       v.typ.toQuery(),
+			Table:      v.table,
+			Conditions: exportConditions(v.conditions),
+		}
+		if v.subQuery != nil {
+			joins[i].Table = v.subQuery
+		}
+	}
+	return joins
+}
+
+// Order is a read-only representation of an `ORDER BY` option.
+type Order struct {
+	// Column is the column or the expression, it's the field if it was a `ORDER BY FIELD` option.
+	Column interface{}
+	// Values is the values of the `ORDER BY FIELD` option.
+	Values []interface{}
+	// Direction is the sorting direction, it's empty if it was not specified.
+	Direction OrderDirection
+	// Nulls is `FIRST` or `LAST` if the `NULL` values were sorted explicitly.
+	Nulls string
+}
+
+// Orders returns the `ORDER BY` options of the query.
+func (q *Query) Orders() []Order {
+	orders := make([]Order, len(q.orders))
+	for i, v := range q.orders {
+		orders[i] = Order{
+			Column:    v.column,
+			Values:    append([]interface{}{}, v.values...),
+			Direction: v.direction,
+			Nulls:     v.nulls.toQuery(),
+		}
+		if v.field != nil {
+			orders[i].Column = v.field
+		}
+	}
+	return orders
+}
+
+// Groups returns the `GROUP BY` columns of the query.
+func (q *Query) Groups() []interface{} {
+	return append([]interface{}{}, q.groups...)
+}
+
+// LimitValues returns the `LIMIT` values of the query, both of them are zero if there's no limit.
+func (q *Query) LimitValues() (from int, count int) {
+	return q.limit.from, q.limit.count
+}
+
+// OffsetValues returns the `LIMIT OFFSET` values of the query.
+func (q *Query) OffsetValues() (count int, offset int) {
+	return q.offset.count, q.offset.offset
+}
+
+// Data returns the data of the `INSERT`, `UPDATE` queries.
+func (q *Query) Data() interface{} {
+	return q.data
+}
+
+// Params returns the parameters of the raw query.
+func (q *Query) Params() []interface{} {
+	return append([]interface{}{}, q.params...)
+}
+
+// exportConditions converts the conditions to the read-only representations.
+func exportConditions(conditions []condition) []Condition {
+	if len(conditions) == 0 {
+		return nil
+	}
+	result := make([]Condition, len(conditions))
+	for i, v := range conditions {
+		result[i] = Condition{
+			Query: v.query,
+			Args:  append([]interface{}{}, v.args...),
+			Or:    v.connector == connectorTypeOr,
+			Group: exportConditions(v.group),
+		}
+		if v.tuple != nil {
+			result[i].TupleColumns = append([]string{}, v.tuple.columns...)
+			result[i].Args = []interface{}{v.tuple.values}
+		}
+	}
+	return result
+}
+
+// Walk calls the function for the query and every nested sub query in depth-first order,
+// the sub queries of a query will be skipped if the function returns false.
+func Walk(q *Query, fn func(q *Query) bool) {
+	if q == nil || !fn(q) {
+		return
+	}
+	for _, v := range q.subQueries() {
+		Walk(v, fn)
+	}
+}
+
+// subQueries returns the direct sub queries of the query.
+func (q *Query) subQueries() (queries []*Query) {
+	var collect func(v interface{})
+	collect = func(v interface{}) {
+		switch j := v.(type) {
+		case *Query:
+			if j != nil {
+				queries = append(queries, j)
+			}
+		case *Expr:
+			for _, p := range j.params {
+				collect(p)
+			}
+		case *Expression:
+			for _, p := range j.values() {
+				collect(p)
+			}
+		case *Aggregate:
+			for _, p := range j.columns {
+				collect(p)
+			}
+		case H:
+			for _, p := range j {
+				collect(p)
+			}
+		case map[string]interface{}:
+			collect(H(j))
+		case []H:
+			for _, p := range j {
+				collect(p)
+			}
+		}
+	}
+	var collectConditions func(conditions []condition)
+	collectConditions = func(conditions []condition) {
+		for _, c := range conditions {
+			for _, a := range c.args {
+				collect(a)
+			}
+			collectConditions(c.group)
+		}
+	}
+
+	collect(q.table)
+	collect(q.subQuery)
+	for _, v := range q.selects {
+		collect(v)
+	}
+	collect(q.data)
+	collect(q.duplicate)
+	for _, v := range q.assignments {
+		collect(v.value)
+	}
+	for _, v := range q.joins {
+		if v.subQuery != nil {
+			collect(v.subQuery)
+		}
+		collectConditions(v.conditions)
+	}
+	collectConditions(q.wheres)
+	collectConditions(q.havings)
+	for _, v := range q.unions {
+		collect(v.query)
+	}
+	for _, v := range q.params {
+		collect(v)
+	}
+	return queries
+}
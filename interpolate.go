@@ -0,0 +1,265 @@
+package rushia
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redacted replaces the value of the redacted columns while interpolating,
+// it's a non-zero value so the redacted columns won't be eliminated by `Patch`.
+type redacted string
+
+// redactedValue is the value of the redacted columns.
+const redactedValue redacted = "[REDACTED]"
+
+// ToSQL builds the query and inlines the parameters as the literals of the query dialect, see `Interpolate` for more details.
+//
+// The result is for debugging and logging only, DO NOT execute it.
+func (q *Query) ToSQL(redactColumns ...string) string {
+	return Interpolate(q, q.dialect, redactColumns...)
+}
+
+// Interpolate builds the query and inlines the parameters as the escaped literals of the dialect,
+// so the query could be copied to the database console directly.
+// The values of the specified columns will be replaced with `'[REDACTED]'`, and the conditions that mention the columns will be redacted too.
+//
+// The result is for debugging and logging only, it's NOT safe to execute since it's not a prepared statement anymore.
+func Interpolate(q *Query, d Dialect, redactColumns ...string) string {
+	if len(redactColumns) != 0 {
+		q = redactQuery(q, redactColumns)
+	}
+	query, params := build(q.Copy().SetDialect(d))
+	return interpolate(quoteIdents(query, d), params, d)
+}
+
+// interpolate replaces the `?` placeholders in the query with the literals of the parameters.
+func interpolate(query string, params []interface{}, d Dialect) string {
+	replacements := make([]string, len(params))
+	for i, v := range params {
+		replacements[i] = literal(v, d)
+	}
+	return replaceTokens(query, filterTokens(lex(query, d), tokenTypePlaceholder), replacements)
+}
+
+// literal converts the value to an escaped SQL literal of the dialect.
+func literal(v interface{}, d Dialect) string {
+	switch j := v.(type) {
+	case nil:
+		return "NULL"
+	case redacted:
+		return quoteString(string(j), d)
+	case driver.Valuer:
+		if val := reflect.ValueOf(j); val.Kind() == reflect.Ptr && val.IsNil() {
+			return "NULL"
+		}
+		value, err := j.Value()
+		if err != nil {
+			panic(fmt.Sprintf("rushia: failed to interpolate the value: %s", err))
+		}
+		return literal(value, d)
+	case bool:
+		if j {
+			return "TRUE"
+		}
+		return "FALSE"
+	case string:
+		return quoteString(j, d)
+	case []byte:
+		if j == nil {
+			return "NULL"
+		}
+		if d == DialectPostgreSQL {
+			return fmt.Sprintf("'\\x%s'", hex.EncodeToString(j))
+		}
+		return fmt.Sprintf("X'%s'", hex.EncodeToString(j))
+	case time.Time:
+		if d == DialectPostgreSQL {
+			return quoteString(j.Format("2006-01-02 15:04:05.999999-07:00"), d)
+		}
+		return quoteString(j.Format("2006-01-02 15:04:05.999999"), d)
+	}
+
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(val.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(val.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(val.Float(), 'g', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(val.Float(), 'g', -1, 64)
+	case reflect.Bool:
+		return literal(val.Bool(), d)
+	case reflect.String:
+		return quoteString(val.String(), d)
+	case reflect.Ptr:
+		if val.IsNil() {
+			return "NULL"
+		}
+		return literal(val.Elem().Interface(), d)
+	}
+	return quoteString(fmt.Sprint(v), d)
+}
+
+// quoteString quotes the string as a SQL string literal of the dialect.
+func quoteString(s string, d Dialect) string {
+	if d == DialectPostgreSQL {
+		return fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", "''"))
+	}
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case 0:
+			b.WriteString(`\0`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\x1a':
+			b.WriteString(`\Z`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// redactQuery returns a copy of the query that the values of the specified columns were replaced,
+// the sub queries will be copied and redacted as well.
+func redactQuery(q *Query, columns []string) *Query {
+	c := q.Copy()
+	isRedacted := func(column string) bool {
+		for _, v := range columns {
+			if strings.EqualFold(v, column) {
+				return true
+			}
+		}
+		return false
+	}
+	var mention *regexp.Regexp
+	{
+		var quoted []string
+		for _, v := range columns {
+			quoted = append(quoted, regexp.QuoteMeta(v))
+		}
+		mention = regexp.MustCompile(fmt.Sprintf("(?i)(^|[^\\w])(%s)([^\\w]|$)", strings.Join(quoted, "|")))
+	}
+	var redactValue func(v interface{}, redactAll bool) interface{}
+	redactValue = func(v interface{}, redactAll bool) interface{} {
+		switch j := v.(type) {
+		case *Query:
+			return redactQuery(j, columns)
+		case *Expr:
+			params := make([]interface{}, len(j.params))
+			for i, p := range j.params {
+				params[i] = redactValue(p, redactAll)
+			}
+			return &Expr{rawQuery: j.rawQuery, params: params, err: j.err}
+		}
+		if !redactAll {
+			return v
+		}
+		if v != nil {
+			if isList(v) {
+				s := make([]interface{}, reflect.ValueOf(v).Len())
+				for i := range s {
+					s[i] = redactedValue
+				}
+				return s
+			}
+		}
+		return redactedValue
+	}
+	redactH := func(h H) H {
+		result := make(H, len(h))
+		for k, v := range h {
+			// Keep the zero value that will be eliminated by `Patch` so the columns of the query won't be changed.
+			if c.typ == QueryTypePatch && c.shouldEliminate(k, v) {
+				result[k] = v
+				continue
+			}
+			result[k] = redactValue(v, isRedacted(k))
+		}
+		return result
+	}
+	var redactConditions func(conditions []condition) []condition
+	redactConditions = func(conditions []condition) []condition {
+		result := make([]condition, len(conditions))
+		for i, v := range conditions {
+			redactAll := mention.MatchString(v.query)
+			v.args = append([]interface{}{}, v.args...)
+			for k, a := range v.args {
+				v.args[k] = redactValue(a, redactAll)
+			}
+			v.group = redactConditions(v.group)
+			if v.tuple != nil {
+				tuples := c.explodeTuples(v.tuple.columns, v.tuple.values)
+				for _, t := range tuples {
+					for k, column := range v.tuple.columns {
+						t[k] = redactValue(t[k], isRedacted(column))
+					}
+				}
+				v.tuple = &tuple{columns: v.tuple.columns, values: tuples}
+			}
+			result[i] = v
+		}
+		return result
+	}
+
+	if c.data != nil {
+		_, _, datas := c.explodeData(c.data, []string{})
+		for i, v := range datas {
+			datas[i] = redactH(v)
+		}
+		c.data = datas
+	}
+	if c.duplicate != nil {
+		c.duplicate = redactH(c.duplicate)
+	}
+	for i, v := range c.assignments {
+		v.value = redactValue(v.value, isRedacted(v.column))
+		c.assignments[i] = v
+	}
+	if v, ok := c.table.(*Query); ok {
+		c.table = redactQuery(v, columns)
+	}
+	if c.subQuery != nil {
+		c.subQuery = redactQuery(c.subQuery, columns)
+	}
+	for i, v := range c.selects {
+		c.selects[i] = redactValue(v, false)
+	}
+	for i, v := range c.joins {
+		if v.subQuery != nil {
+			v.subQuery = redactQuery(v.subQuery, columns)
+		}
+		v.conditions = redactConditions(v.conditions)
+		c.joins[i] = v
+	}
+	c.wheres = redactConditions(c.wheres)
+	c.havings = redactConditions(c.havings)
+	for i, v := range c.unions {
+		v.query = redactQuery(v.query, columns)
+		c.unions[i] = v
+	}
+	if c.typ == QueryTypeRawQuery {
+		redactAll := mention.MatchString(c.rawQuery)
+		for i, v := range c.params {
+			c.params[i] = redactValue(v, redactAll)
+		}
+	}
+	return c
+}
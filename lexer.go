@@ -0,0 +1,146 @@
+package rushia
+
+const (
+	// tokenTypePlaceholder is a `?` placeholder.
+	tokenTypePlaceholder tokenType = iota
+	// tokenTypeEscape is a `??` escaped identifier placeholder.
+	tokenTypeEscape
+	// tokenTypeNamed is a `:name` named parameter.
+	tokenTypeNamed
+	// tokenTypeIdent is a backtick-quoted identifier.
+	tokenTypeIdent
+)
+
+type tokenType int
+
+// token is a placeholder found in the SQL query, the `start` and `end` are the byte offsets in the query.
+type token struct {
+	typ   tokenType
+	start int
+	end   int
+}
+
+// lex scans the SQL query and returns the placeholders in order,
+// the symbols in the quoted strings, the quoted identifiers, the comments and the JSON operators (e.g. `?|`, `?&`) are ignored.
+// The backtick-quoted identifiers are returned as well so they could be converted for the other dialects.
+//
+// The `#` comments are MySQL only, since `#` is the XOR operator in PostgreSQL, and the `#>`, `#>>` JSON operators
+// are never taken as the comments since the dialect is unknown before the query was built.
+func lex(query string, d Dialect) (tokens []token) {
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		// `identifier`
+		case c == '`':
+			j := skipQuoted(query, i)
+			end := j + 1
+			// The identifier was not closed.
+			if end > len(query) {
+				end = len(query)
+			}
+			tokens = append(tokens, token{typ: tokenTypeIdent, start: i, end: end})
+			i = j
+
+		// 'string', "string"
+		case c == '\'' || c == '"':
+			i = skipQuoted(query, i)
+
+		// -- comment, # comment
+		case isLineComment(query, i, d):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+
+		// /* comment */
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			i += 2
+			for i < len(query) && !(query[i] == '*' && i+1 < len(query) && query[i+1] == '/') {
+				i++
+			}
+			i++
+
+		case c == '?':
+			switch {
+			case i+1 < len(query) && query[i+1] == '?':
+				tokens = append(tokens, token{typ: tokenTypeEscape, start: i, end: i + 2})
+				i++
+			// The `?|` and `?&` JSON operators in PostgreSQL.
+			case i+1 < len(query) && (query[i+1] == '|' || query[i+1] == '&'):
+				i++
+			default:
+				tokens = append(tokens, token{typ: tokenTypePlaceholder, start: i, end: i + 1})
+			}
+
+		// :name, but not the `::` type casts.
+		case c == ':' && i+1 < len(query) && isNameStart(query[i+1]) && (i == 0 || query[i-1] != ':'):
+			j := i + 1
+			for j < len(query) && isNamePart(query[j]) {
+				j++
+			}
+			tokens = append(tokens, token{typ: tokenTypeNamed, start: i, end: j})
+			i = j - 1
+		}
+	}
+	return tokens
+}
+
+// isLineComment reports whether a line comment starts at the offset.
+func isLineComment(query string, i int, d Dialect) bool {
+	switch query[i] {
+	case '-':
+		return i+1 < len(query) && query[i+1] == '-'
+	case '#':
+		return d == DialectMySQL && !(i+1 < len(query) && query[i+1] == '>')
+	}
+	return false
+}
+
+// skipQuoted returns the offset of the closing quote that matches the quote at the start offset,
+// the doubled quotes and the backslash escapes (except in the identifiers) are skipped.
+func skipQuoted(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(query)
+}
+
+// filterTokens returns the tokens of the specified types.
+func filterTokens(tokens []token, types ...tokenType) (result []token) {
+	for _, v := range tokens {
+		for _, t := range types {
+			if v.typ == t {
+				result = append(result, v)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// replaceTokens replaces the tokens in the query with the replacements in one pass,
+// so the replaced content won't shift the position of the later tokens.
+func replaceTokens(query string, tokens []token, replacements []string) string {
+	var (
+		result string
+		last   int
+	)
+	for i, v := range tokens {
+		if i >= len(replacements) {
+			break
+		}
+		result += query[last:v.start] + replacements[i]
+		last = v.end
+	}
+	return result + query[last:]
+}
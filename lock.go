@@ -0,0 +1,145 @@
+package rushia
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrLockNotSelect is returned when a locking clause (e.g. `ForUpdate`) was applied to a query that is not a `SELECT` query.
+	ErrLockNotSelect = errors.New("rushia: the locking clause can only be used in a SELECT query")
+	// ErrUnsupportedLock is returned when the locking clause was not supported by the dialect (e.g. `LOCK IN SHARE MODE` in PostgreSQL).
+	ErrUnsupportedLock = errors.New("rushia: unsupported locking clause")
+)
+
+const (
+	lockStrengthUpdate lockStrength = iota
+	lockStrengthShare
+	lockStrengthShareMode
+)
+
+type lockStrength int
+
+func (s lockStrength) toQuery() string {
+	switch s {
+	case lockStrengthShare:
+		return "FOR SHARE"
+	case lockStrengthShareMode:
+		return "LOCK IN SHARE MODE"
+	default:
+		return "FOR UPDATE"
+	}
+}
+
+const (
+	lockWaitDefault lockWait = iota
+	lockWaitNoWait
+	lockWaitSkipLocked
+)
+
+type lockWait int
+
+func (w lockWait) toQuery() string {
+	switch w {
+	case lockWaitNoWait:
+		return "NOWAIT"
+	case lockWaitSkipLocked:
+		return "SKIP LOCKED"
+	default:
+		return ""
+	}
+}
+
+// lock is the row locking clause of a `SELECT` query.
+type lock struct {
+	strength lockStrength
+	wait     lockWait
+	tables   []string
+}
+
+// LockOption modifies the row locking clause of `ForUpdate` and `ForShare`.
+type LockOption func(l *lock)
+
+// NoWait fails immediately instead of waiting if the rows were locked by the other transactions.
+func NoWait() LockOption {
+	return func(l *lock) {
+		l.wait = lockWaitNoWait
+	}
+}
+
+// SkipLocked skips the rows that were locked by the other transactions, it's useful for the job queues.
+func SkipLocked() LockOption {
+	return func(l *lock) {
+		l.wait = lockWaitSkipLocked
+	}
+}
+
+// Of locks the rows of the specified tables (or the aliases) only.
+func Of(tables ...string) LockOption {
+	return func(l *lock) {
+		l.tables = append(l.tables, tables...)
+	}
+}
+
+// ForUpdate locks the selected rows for updating (`FOR UPDATE`), use `NoWait`, `SkipLocked` and `Of` to modify the lock,
+// the options require MySQL 8.0 or later. It can only be used in a `SELECT` query, otherwise `ErrLockNotSelect` will be returned while building.
+func (q *Query) ForUpdate(options ...LockOption) *Query {
+	return q.putLock(lockStrengthUpdate, options)
+}
+
+// ForShare locks the selected rows in the shared mode (`FOR SHARE`), use `NoWait`, `SkipLocked` and `Of` to modify the lock.
+// It requires MySQL 8.0 or later, use `LockInShareMode` for the older versions.
+// It can only be used in a `SELECT` query, otherwise `ErrLockNotSelect` will be returned while building.
+func (q *Query) ForShare(options ...LockOption) *Query {
+	return q.putLock(lockStrengthShare, options)
+}
+
+// LockInShareMode locks the selected rows in the shared mode with the legacy syntax of MySQL (`LOCK IN SHARE MODE`),
+// it works with MySQL 5.7 but it doesn't support the lock options. `ErrUnsupportedLock` will be returned while building in PostgreSQL.
+func (q *Query) LockInShareMode() *Query {
+	return q.putLock(lockStrengthShareMode, nil)
+}
+
+func (q *Query) putLock(strength lockStrength, options []LockOption) *Query {
+	l := &lock{strength: strength}
+	for _, o := range options {
+		o(l)
+	}
+	q.lock = l
+	return q
+}
+
+// checkLock returns ErrLockNotSelect if the query is locked but it's not a `SELECT` query,
+// or ErrUnsupportedLock if the locking clause was not supported by the dialect.
+func (q *Query) checkLock() error {
+	if q.lock == nil {
+		return nil
+	}
+	if q.typ != QueryTypeSelect {
+		return ErrLockNotSelect
+	}
+	if q.lock.strength == lockStrengthShareMode && q.dialect != DialectMySQL {
+		return fmt.Errorf("%w: LOCK IN SHARE MODE is only supported by MySQL, use ForShare instead", ErrUnsupportedLock)
+	}
+	return nil
+}
+
+// buildLock builds the row locking clause (e.g. `FOR UPDATE OF t1 SKIP LOCKED`).
+func (q *Query) buildLock() string {
+	if q.lock == nil {
+		return ""
+	}
+	parts := []string{q.lock.strength.toQuery()}
+	if len(q.lock.tables) != 0 {
+		tables := make([]string, len(q.lock.tables))
+		for i, v := range q.lock.tables {
+			tables[i] = quoteIdent(v)
+		}
+		parts = append(parts, fmt.Sprintf("OF %s", strings.Join(tables, ", ")))
+	}
+	if wait := q.lock.wait.toQuery(); wait != "" {
+		parts = append(parts, wait)
+	}
+	return strings.Join(parts, " ")
+}
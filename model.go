@@ -0,0 +1,120 @@
+package rushia
+
+import (
+	"reflect"
+
+	"github.com/iancoleman/strcase"
+)
+
+// Tabler is implemented by the models that define their own table name.
+type Tabler interface {
+	TableName() string
+}
+
+// model is the metadata of a struct that was passed to `NewModelQuery`.
+type model struct {
+	value    interface{}
+	pks      []string
+	pkValues []interface{}
+}
+
+// NewModelQuery creates a Query based on a struct, the table name comes from the `TableName` method (see `Tabler`),
+// or the snake case of the struct name if the method was not defined.
+//
+// The primary keys are the fields with the `pk` option in the rushia struct tag (e.g. `rushia:"id,pk"`),
+// the field named as `id` will be used if there's no field was tagged.
+func NewModelQuery(v interface{}) *Query {
+	val := reflect.Indirect(reflect.ValueOf(v))
+	if val.Kind() != reflect.Struct {
+		panic("rushia: model must be a struct or a pointer to a struct")
+	}
+	var table string
+	if t, ok := v.(Tabler); ok {
+		table = t.TableName()
+	} else {
+		table = strcase.ToSnake(val.Type().Name())
+	}
+	if table == "" {
+		panic("rushia: cannot resolve the table name of the model, implement the Tabler interface")
+	}
+	q := NewQuery(table)
+	q.model = explodeModel(val)
+	q.model.value = v
+	return q
+}
+
+// explodeModel collects the primary keys and the values from a struct value.
+func explodeModel(val reflect.Value) *model {
+	var (
+		m           = &model{}
+		idValue     interface{}
+		hasIDColumn bool
+	)
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		column, options, ok := parseFieldTag(t.Field(i))
+		if !ok {
+			continue
+		}
+		for _, o := range options {
+			if o == "pk" {
+				m.pks = append(m.pks, column)
+				m.pkValues = append(m.pkValues, val.Field(i).Interface())
+				break
+			}
+		}
+		if column == "id" {
+			hasIDColumn = true
+			idValue = val.Field(i).Interface()
+		}
+	}
+	if len(m.pks) == 0 && hasIDColumn {
+		m.pks = []string{"id"}
+		m.pkValues = []interface{}{idValue}
+	}
+	return m
+}
+
+// mustModel returns the model of the query, panics if the query was not created by `NewModelQuery`.
+func (q *Query) mustModel(requirePK bool) *model {
+	if q.model == nil {
+		panic("rushia: the query has no model, create it with NewModelQuery")
+	}
+	if requirePK && len(q.model.pks) == 0 {
+		panic("rushia: no primary key was found in the model")
+	}
+	return q.model
+}
+
+// wherePK creates the `WHERE` conditions for each primary key of the model.
+func (q *Query) wherePK(m *model) *Query {
+	for i, pk := range m.pks {
+		q.Where("?? = ?", pk, m.pkValues[i])
+	}
+	return q
+}
+
+// InsertModel creates a `INSERT INTO` query with the model as the data.
+func (q *Query) InsertModel() *Query {
+	return q.Insert(q.mustModel(false).value)
+}
+
+// UpdateModel creates a `UPDATE` query with the model as the data,
+// it updates the row by the primary keys and the primary keys won't be updated.
+func (q *Query) UpdateModel() *Query {
+	m := q.mustModel(true)
+	return q.wherePK(m).Omit(m.pks...).Update(m.value)
+}
+
+// DeleteModel creates a `DELETE` query that deletes the row by the primary keys of the model.
+func (q *Query) DeleteModel() *Query {
+	return q.wherePK(q.mustModel(true)).Delete()
+}
+
+// FindByPK creates a `SELECT` query that fetches the row by the primary keys of the model.
+func (q *Query) FindByPK(columns ...interface{}) *Query {
+	return q.wherePK(q.mustModel(true)).SelectOne(columns...)
+}
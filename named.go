@@ -0,0 +1,80 @@
+package rushia
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrMissingNamedParam is returned when a named parameter in the query was not found in the `Named` values.
+	ErrMissingNamedParam = errors.New("rushia: missing named parameter")
+	// ErrUnusedNamedParam is returned when a `Named` value was not used in the query.
+	ErrUnusedNamedParam = errors.New("rushia: unused named parameter")
+	// ErrMixedNamedParam is returned when the `Named` values were passed with the other arguments.
+	ErrMixedNamedParam = errors.New("rushia: named parameters cannot be mixed with the positional arguments")
+)
+
+// Named is the named parameters of a query, the `:name` in the query will be replaced with the `?` placeholder,
+// and the value will be duplicated if the name was used multiple times.
+type Named map[string]interface{}
+
+// processNamed converts the named parameters in the query into the positional `?` placeholders,
+// returns the original query and the arguments if there's no `Named` in the arguments.
+func processNamed(query string, args []interface{}, d Dialect) (string, []interface{}, error) {
+	var named Named
+	for _, v := range args {
+		if n, ok := v.(Named); ok {
+			named = n
+			break
+		}
+	}
+	if named == nil {
+		return query, args, nil
+	}
+	if len(args) != 1 {
+		return query, args, ErrMixedNamedParam
+	}
+	tokens := lex(query, d)
+	if len(filterTokens(tokens, tokenTypePlaceholder, tokenTypeEscape)) != 0 {
+		return query, args, ErrMixedNamedParam
+	}
+	var (
+		result       []interface{}
+		replacements []string
+		used         = make(map[string]bool)
+	)
+	tokens = filterTokens(tokens, tokenTypeNamed)
+	for _, t := range tokens {
+		name := query[t.start+1 : t.end]
+		v, ok := named[name]
+		if !ok {
+			return query, args, fmt.Errorf("%w: %s", ErrMissingNamedParam, name)
+		}
+		used[name] = true
+		result = append(result, v)
+		replacements = append(replacements, "?")
+	}
+	var unused []string
+	for k := range named {
+		if !used[k] {
+			unused = append(unused, k)
+		}
+	}
+	if len(unused) != 0 {
+		sort.Strings(unused)
+		return query, args, fmt.Errorf("%w: %s", ErrUnusedNamedParam, strings.Join(unused, ", "))
+	}
+	return replaceTokens(query, tokens, replacements), result, nil
+}
+
+// isNameStart returns true if the character is able to be the first character of a parameter name.
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isNamePart returns true if the character is able to be a part of a parameter name.
+func isNamePart(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,307 @@
+package rushia
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnknownQueryOption is returned when a query option was not a known option of any statement.
+	ErrUnknownQueryOption = errors.New("rushia: unknown query option")
+	// ErrUnsupportedQueryOption is returned when a query option was not supported by the statement or the dialect,
+	// or it conflicts with another option (e.g. `DISTINCT` with `ALL`).
+	ErrUnsupportedQueryOption = errors.New("rushia: unsupported query option")
+)
+
+// SelectOption is an option of the `SELECT` statement (e.g. `DISTINCT`, `SQL_NO_CACHE`).
+type SelectOption string
+
+const (
+	// SelectAll returns all the matching rows, it's the default behaviour.
+	SelectAll SelectOption = "ALL"
+	// SelectDistinct removes the duplicated rows from the result.
+	SelectDistinct SelectOption = "DISTINCT"
+	// SelectDistinctRow is a synonym of `DISTINCT` in MySQL.
+	SelectDistinctRow SelectOption = "DISTINCTROW"
+	// SelectHighPriority gives the query a higher priority than the updating statements.
+	SelectHighPriority SelectOption = "HIGH_PRIORITY"
+	// SelectStraightJoin forces the optimizer to join the tables in the order they were listed.
+	SelectStraightJoin SelectOption = "STRAIGHT_JOIN"
+	// SelectSmallResult tells the optimizer that the result set is small.
+	SelectSmallResult SelectOption = "SQL_SMALL_RESULT"
+	// SelectBigResult tells the optimizer that the result set is large.
+	SelectBigResult SelectOption = "SQL_BIG_RESULT"
+	// SelectBufferResult puts the result in a temporary table to release the table locks early.
+	SelectBufferResult SelectOption = "SQL_BUFFER_RESULT"
+	// SelectCache caches the result in the query cache (MySQL 5.7 and before).
+	SelectCache SelectOption = "SQL_CACHE"
+	// SelectNoCache doesn't cache the result in the query cache.
+	SelectNoCache SelectOption = "SQL_NO_CACHE"
+	// SelectCalcFoundRows calculates the total rows without the `LIMIT` option.
+	SelectCalcFoundRows SelectOption = "SQL_CALC_FOUND_ROWS"
+)
+
+// InsertOption is an option of the `INSERT` and `REPLACE` statements (e.g. `IGNORE`).
+type InsertOption string
+
+const (
+	// InsertLowPriority delays the inserting until no other clients are reading the table.
+	InsertLowPriority InsertOption = "LOW_PRIORITY"
+	// InsertDelayed queues the rows and returns immediately (MySQL 5.7 and before).
+	InsertDelayed InsertOption = "DELAYED"
+	// InsertHighPriority overrides the `--low-priority-updates` option of the server.
+	InsertHighPriority InsertOption = "HIGH_PRIORITY"
+	// InsertIgnore ignores the rows that cause the errors (e.g. the duplicated keys).
+	InsertIgnore InsertOption = "IGNORE"
+)
+
+// UpdateOption is an option of the `UPDATE` statement (e.g. `LOW_PRIORITY`).
+type UpdateOption string
+
+const (
+	// UpdateLowPriority delays the updating until no other clients are reading the table.
+	UpdateLowPriority UpdateOption = "LOW_PRIORITY"
+	// UpdateIgnore ignores the rows that cause the errors (e.g. the duplicated keys).
+	UpdateIgnore UpdateOption = "IGNORE"
+)
+
+// DeleteOption is an option of the `DELETE` statement (e.g. `QUICK`).
+type DeleteOption string
+
+const (
+	// DeleteLowPriority delays the deleting until no other clients are reading the table.
+	DeleteLowPriority DeleteOption = "LOW_PRIORITY"
+	// DeleteQuick doesn't merge the index leaves while deleting.
+	DeleteQuick DeleteOption = "QUICK"
+	// DeleteIgnore ignores the errors while deleting the rows.
+	DeleteIgnore DeleteOption = "IGNORE"
+)
+
+const (
+	queryOptionKindAny queryOptionKind = iota
+	queryOptionKindSelect
+	queryOptionKindInsert
+	queryOptionKindUpdate
+	queryOptionKindDelete
+)
+
+type queryOptionKind int
+
+func (k queryOptionKind) toQuery() string {
+	switch k {
+	case queryOptionKindSelect:
+		return "SELECT"
+	case queryOptionKindInsert:
+		return "INSERT"
+	case queryOptionKindUpdate:
+		return "UPDATE"
+	case queryOptionKindDelete:
+		return "DELETE"
+	default:
+		return ""
+	}
+}
+
+// queryOption is an option that will be placed right after the statement keyword.
+type queryOption struct {
+	kind  queryOptionKind
+	value string
+}
+
+// optionSpec describes the options of a statement in the rendering order, the options in the same group are mutually exclusive.
+type optionSpec [][]string
+
+var (
+	selectOptionSpec = optionSpec{
+		{string(SelectAll), string(SelectDistinct), string(SelectDistinctRow)},
+		{string(SelectHighPriority)},
+		{string(SelectStraightJoin)},
+		{string(SelectSmallResult)},
+		{string(SelectBigResult)},
+		{string(SelectBufferResult)},
+		{string(SelectCache), string(SelectNoCache)},
+		{string(SelectCalcFoundRows)},
+	}
+	insertOptionSpec = optionSpec{
+		{string(InsertLowPriority), string(InsertDelayed), string(InsertHighPriority)},
+		{string(InsertIgnore)},
+	}
+	// replaceOptionSpec is the options of the `REPLACE` statement, it doesn't support `HIGH_PRIORITY` and `IGNORE`.
+	replaceOptionSpec = optionSpec{
+		{string(InsertLowPriority), string(InsertDelayed)},
+	}
+	updateOptionSpec = optionSpec{
+		{string(UpdateLowPriority)},
+		{string(UpdateIgnore)},
+	}
+	deleteOptionSpec = optionSpec{
+		{string(DeleteLowPriority)},
+		{string(DeleteQuick)},
+		{string(DeleteIgnore)},
+	}
+	// postgresSelectOptionSpec is the options of the `SELECT` statement that PostgreSQL supports.
+	postgresSelectOptionSpec = optionSpec{
+		{string(SelectAll), string(SelectDistinct)},
+	}
+)
+
+// group returns the index of the group that contains the option, it returns -1 if the option was not in the spec.
+func (s optionSpec) group(option string) int {
+	for i, g := range s {
+		for _, v := range g {
+			if v == option {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// SelectOptions adds the options to the `SELECT` statement (e.g. `SelectOptions(rushia.SelectDistinct, rushia.SelectNoCache)`).
+func (q *Query) SelectOptions(options ...SelectOption) *Query {
+	for _, v := range options {
+		q.putQueryOption(queryOptionKindSelect, string(v))
+	}
+	return q
+}
+
+// InsertOptions adds the options to the `INSERT` or the `REPLACE` statement (e.g. `InsertOptions(rushia.InsertLowPriority)`).
+func (q *Query) InsertOptions(options ...InsertOption) *Query {
+	for _, v := range options {
+		q.putQueryOption(queryOptionKindInsert, string(v))
+	}
+	return q
+}
+
+// UpdateOptions adds the options to the `UPDATE` statement (e.g. `UpdateOptions(rushia.UpdateLowPriority)`).
+func (q *Query) UpdateOptions(options ...UpdateOption) *Query {
+	for _, v := range options {
+		q.putQueryOption(queryOptionKindUpdate, string(v))
+	}
+	return q
+}
+
+// DeleteOptions adds the options to the `DELETE` statement (e.g. `DeleteOptions(rushia.DeleteQuick)`).
+func (q *Query) DeleteOptions(options ...DeleteOption) *Query {
+	for _, v := range options {
+		q.putQueryOption(queryOptionKindDelete, string(v))
+	}
+	return q
+}
+
+// InsertIgnore ignores the rows that cause the errors (e.g. the duplicated keys) while inserting (`INSERT IGNORE`).
+func (q *Query) InsertIgnore() *Query {
+	return q.InsertOptions(InsertIgnore)
+}
+
+// UpdateIgnore ignores the rows that cause the errors (e.g. the duplicated keys) while updating (`UPDATE IGNORE`).
+func (q *Query) UpdateIgnore() *Query {
+	return q.UpdateOptions(UpdateIgnore)
+}
+
+func (q *Query) putQueryOption(kind queryOptionKind, value string) {
+	q.queryOptions = append(q.queryOptions, queryOption{kind: kind, value: value})
+}
+
+// optionKind returns the kind of the options that the query type accepts.
+func (q *Query) optionKind() queryOptionKind {
+	switch q.typ {
+	case QueryTypeSelect, QueryTypeExists:
+		return queryOptionKindSelect
+	case QueryTypeInsert, QueryTypeReplace, QueryTypeInsertSelect:
+		return queryOptionKindInsert
+	case QueryTypeUpdate, QueryTypePatch:
+		return queryOptionKindUpdate
+	case QueryTypeDelete:
+		return queryOptionKindDelete
+	default:
+		return queryOptionKindAny
+	}
+}
+
+// optionSpec returns the options that the query type and the dialect support.
+func (q *Query) optionSpec() optionSpec {
+	switch q.optionKind() {
+	case queryOptionKindSelect:
+		if q.dialect == DialectPostgreSQL {
+			return postgresSelectOptionSpec
+		}
+		return selectOptionSpec
+	case queryOptionKindInsert:
+		if q.dialect == DialectPostgreSQL {
+			return nil
+		}
+		if q.typ == QueryTypeReplace {
+			return replaceOptionSpec
+		}
+		return insertOptionSpec
+	case queryOptionKindUpdate:
+		if q.dialect == DialectPostgreSQL {
+			return nil
+		}
+		return updateOptionSpec
+	case queryOptionKindDelete:
+		if q.dialect == DialectPostgreSQL {
+			return nil
+		}
+		return deleteOptionSpec
+	default:
+		return nil
+	}
+}
+
+// isKnownOption returns true if the option is an option of any statement.
+func isKnownOption(option string) bool {
+	for _, s := range []optionSpec{selectOptionSpec, insertOptionSpec, updateOptionSpec, deleteOptionSpec} {
+		if s.group(option) != -1 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOptions returns ErrUnknownQueryOption or ErrUnsupportedQueryOption if an option can't be used in the query.
+func (q *Query) checkOptions() error {
+	kind := q.optionKind()
+	spec := q.optionSpec()
+	chosen := make(map[int]string)
+	for _, v := range q.queryOptions {
+		if !isKnownOption(v.value) {
+			return fmt.Errorf("%w: %s", ErrUnknownQueryOption, v.value)
+		}
+		if v.kind != queryOptionKindAny && v.kind != kind {
+			return fmt.Errorf("%w: %s option %s in a %s query", ErrUnsupportedQueryOption, v.kind.toQuery(), v.value, q.typ)
+		}
+		i := spec.group(v.value)
+		if i == -1 {
+			return fmt.Errorf("%w: %s in a %s query", ErrUnsupportedQueryOption, v.value, q.typ)
+		}
+		if c, ok := chosen[i]; ok && c != v.value {
+			return fmt.Errorf("%w: %s conflicts with %s", ErrUnsupportedQueryOption, v.value, c)
+		}
+		chosen[i] = v.value
+	}
+	return nil
+}
+
+// buildQueryOptions builds the options in the rendering order of the statement, the duplicated options are rendered once.
+func (q *Query) buildQueryOptions() string {
+	if len(q.queryOptions) == 0 {
+		return ""
+	}
+	spec := q.optionSpec()
+	chosen := make(map[int]string)
+	for _, v := range q.queryOptions {
+		if i := spec.group(v.value); i != -1 {
+			chosen[i] = v.value
+		}
+	}
+	var parts []string
+	for i := range spec {
+		if v, ok := chosen[i]; ok {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
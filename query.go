@@ -1,11 +1,13 @@
 package rushia
 
 import (
+	"fmt"
 	"reflect"
 )
 
 // Copy creates a copy of the current query,
 // so you are able to make changes and it won't modify the original query.
+// The sub queries and the values are still shared, use `Clone` to copy the whole query tree.
 func (q *Query) Copy() *Query {
 	a := *q
 	b := a
@@ -16,7 +18,7 @@ func (q *Query) Copy() *Query {
 	b.havings = make([]condition, len(a.havings))
 	copy(b.havings, a.havings)
 	//
-	b.queryOptions = make([]string, len(a.queryOptions))
+	b.queryOptions = make([]queryOption, len(a.queryOptions))
 	copy(b.queryOptions, a.queryOptions)
 	//
 	b.unions = make([]union, len(a.unions))
@@ -27,25 +29,59 @@ func (q *Query) Copy() *Query {
 	//
 	b.joins = make([]join, len(a.joins))
 	copy(b.joins, a.joins)
+	for i, v := range b.joins {
+		b.joins[i].conditions = append([]condition{}, v.conditions...)
+		b.joins[i].indexHints = append([]IndexHint{}, v.indexHints...)
+	}
+	//
+	if a.duplicate != nil {
+		b.duplicate = make(H, len(a.duplicate))
+		for k, v := range a.duplicate {
+			b.duplicate[k] = v
+		}
+	}
+	//
+	b.exclude.kinds = append([]reflect.Kind{}, a.exclude.kinds...)
+	b.exclude.fields = append([]string{}, a.exclude.fields...)
 	//
 	b.orders = make([]order, len(a.orders))
 	copy(b.orders, a.orders)
 	//
-	b.groups = make([]string, len(a.groups))
+	b.groups = make([]interface{}, len(a.groups))
 	copy(b.groups, a.groups)
 	//
+	b.groupingSets = make([][]interface{}, len(a.groupingSets))
+	copy(b.groupingSets, a.groupingSets)
+	//
 	b.params = make([]interface{}, len(a.params))
 	copy(b.params, a.params)
 	//
 	b.omits = make([]string, len(a.omits))
 	copy(b.omits, a.omits)
+	//
+	b.scopes = make([]Scope, len(a.scopes))
+	copy(b.scopes, a.scopes)
+	//
+	b.assignments = make([]assignment, len(a.assignments))
+	copy(b.assignments, a.assignments)
+	//
+	b.indexHints = append([]IndexHint{}, a.indexHints...)
+	b.optimizerHints = append([]string{}, a.optimizerHints...)
+	//
+	b.comments = append([]string{}, a.comments...)
+	if a.tags != nil {
+		b.tags = make(map[string]string, len(a.tags))
+		for k, v := range a.tags {
+			b.tags[k] = v
+		}
+	}
 	return &b
 }
 
 // Insert creates a `INSERT INTO` query with specified data.
 // It inserts a data to the database.
 func (q *Query) Insert(v interface{}) *Query {
-	q.typ = queryTypeInsert
+	q.typ = QueryTypeInsert
 	q.data = v
 	return q
 }
@@ -53,7 +89,7 @@ func (q *Query) Insert(v interface{}) *Query {
 // Replace creates a `REPLACE INTO` query with specified data.
 // It deletes the original data and creates a new one instead, prettry dangerous if the data contains a foreign key.
 func (q *Query) Replace(v interface{}) *Query {
-	q.typ = queryTypeReplace
+	q.typ = QueryTypeReplace
 	q.data = v
 	return q
 }
@@ -61,15 +97,16 @@ func (q *Query) Replace(v interface{}) *Query {
 // Update creates a `UPDATE` query with specified data.
 // It updates the data with new data, normally use with `WHERE` condition.
 func (q *Query) Update(v interface{}) *Query {
-	q.typ = queryTypeUpdate
+	q.typ = QueryTypeUpdate
 	q.data = v
 	return q
 }
 
 // Select creates a `SELECT` query with specified columns, can be empty for select everything (`*`).
+// The columns could be the strings, `Ident`, `Raw`, `Col`, expressions or sub queries.
 // It fetches the data from database.
 func (q *Query) Select(columns ...interface{}) *Query {
-	q.typ = queryTypeSelect
+	q.typ = QueryTypeSelect
 	q.selects = columns
 	return q
 }
@@ -78,38 +115,75 @@ func (q *Query) Select(columns ...interface{}) *Query {
 // It's the combination of `.Limit(1).Select()`.
 func (q *Query) SelectOne(columns ...interface{}) *Query {
 	q.Limit(1)
-	q.typ = queryTypeSelect
+	q.typ = QueryTypeSelect
 	q.selects = columns
 	return q
 }
 
+// UpdateBatch creates an `UPDATE` query that updates multiple rows with the different values in one query,
+// the rows could be a `[]H` or a struct slice like `Insert` does, and each row must contain the key column.
+//
+// It will be built as `SET Column = CASE Key WHEN ? THEN ? ... END WHERE Key IN (...)`. Use `Chunk` to split a large batch.
+func (q *Query) UpdateBatch(rows interface{}, key string) *Query {
+	q.typ = QueryTypeUpdate
+	q.data = rows
+	q.batchKey = key
+	return q
+}
+
+// Chunk splits the rows of the `Insert`, `Replace` or `UpdateBatch` query into the queries that contain at most `size` rows,
+// so a large batch won't exceed the placeholder limit of the database.
+func (q *Query) Chunk(size int) []*Query {
+	if q.data == nil || size <= 0 {
+		return []*Query{q.Copy()}
+	}
+	_, _, datas := q.explodeData(q.data, []string{})
+	var queries []*Query
+	for i := 0; i < len(datas); i += size {
+		end := i + size
+		if end > len(datas) {
+			end = len(datas)
+		}
+		c := q.Copy()
+		c.data = datas[i:end]
+		queries = append(queries, c)
+	}
+	return queries
+}
+
 // Patch works the same as `Update` but ignores the zero value.
 // The zero value fields won't be updated unless it's in exclude list, to define the list, call `Exclude`.
 func (q *Query) Patch(v interface{}) *Query {
-	q.typ = queryTypePatch
+	q.typ = QueryTypePatch
 	q.data = v
 	return q
 }
 
 // Exists creates a `SELECT EXISTS` query, returns a result if the query does match a row.
 func (q *Query) Exists() *Query {
-	q.typ = queryTypeExists
+	q.typ = QueryTypeExists
 	return q
 }
 
 // InsertSelect creates a `INSERT SELECT` query, it works a bit like table copy.
 // The insert data is from another selection, pass a `SELECT` query to the first argument.
 func (q *Query) InsertSelect(qu *Query, columns ...interface{}) *Query {
-	q.typ = queryTypeInsertSelect
+	q.typ = QueryTypeInsertSelect
 	q.subQuery = qu
 	q.selects = columns
 	return q
 }
 
 // Delete creates a `DELETE` query to delete the data.
-// Make sure you are using it with `WHERE` condition to not delete all the data.
+// Make sure you are using it with `WHERE` condition to not delete all the data, or enable `RequireWhere` to prevent it.
 func (q *Query) Delete() *Query {
-	q.typ = queryTypeDelete
+	q.typ = QueryTypeDelete
+	return q
+}
+
+// AllowFullTable allows the `UPDATE`, `PATCH` and `DELETE` queries to be built without `WHERE` condition while `RequireWhere` is enabled.
+func (q *Query) AllowFullTable() *Query {
+	q.allowFullTable = true
 	return q
 }
 
@@ -131,6 +205,51 @@ func (q *Query) OnDuplicate(v H) *Query {
 	return q
 }
 
+// Increment increases the column by the value atomically (e.g. `Views = Views + 1`).
+// It creates an `UPDATE` query if the query type was not set, and it will be appended to the `ON DUPLICATE KEY UPDATE` list for an `INSERT` query.
+func (q *Query) Increment(column string, value interface{}) *Query {
+	return q.putAssignment(column, Add(Ident(column), value))
+}
+
+// Decrement decreases the column by the value atomically (e.g. `Stock = Stock - 1`), call `Min` to prevent it from going below a value.
+// It creates an `UPDATE` query if the query type was not set, and it will be appended to the `ON DUPLICATE KEY UPDATE` list for an `INSERT` query.
+func (q *Query) Decrement(column string, value interface{}) *Query {
+	return q.putAssignment(column, Sub(Ident(column), value))
+}
+
+// SetExpr sets the column to the expression (e.g. `NewExpr`, `Case`), it overrides the same column in the `Update` data.
+func (q *Query) SetExpr(column string, expr interface{}) *Query {
+	return q.putAssignment(column, expr)
+}
+
+// SetColumn sets the column to the value of another column (e.g. `SetColumn("PrevStatus", "Status")`).
+func (q *Query) SetColumn(column string, source string) *Query {
+	return q.putAssignment(column, Ident(source))
+}
+
+// Min clamps the latest `Increment`, `Decrement` or `SetExpr` value so it won't go below the value (e.g. `GREATEST(Stock - ?, 0)`).
+// An error will be returned while building if there's no assignment to clamp.
+func (q *Query) Min(value interface{}) *Query {
+	return q.clampAssignment("Min", "GREATEST", value)
+}
+
+// Max clamps the latest `Increment`, `Decrement` or `SetExpr` value so it won't go above the value (e.g. `LEAST(Stock + ?, 100)`).
+// An error will be returned while building if there's no assignment to clamp.
+func (q *Query) Max(value interface{}) *Query {
+	return q.clampAssignment("Max", "LEAST", value)
+}
+
+// clampAssignment wraps the latest assignment with the function (e.g. `GREATEST`, `LEAST`).
+func (q *Query) clampAssignment(method string, function string, value interface{}) *Query {
+	if len(q.assignments) == 0 {
+		q.setErr(fmt.Errorf("rushia: %s requires a prior Increment, Decrement or SetExpr", method))
+		return q
+	}
+	v := &q.assignments[len(q.assignments)-1]
+	v.value = Func(function, v.value, value)
+	return q
+}
+
 // Exclude excludes the specified fields, data types while patching with `Patch` method.
 // Pass string values as field names, and `reflect.Kind` as data types to exclude.
 // While patching, all the zero values will be ignored unless it's in the exclude list.
@@ -185,7 +304,114 @@ func (q *Query) ClearLimit() *Query {
 	return q
 }
 
+// ClearWhere removes all the `WHERE` conditions.
+func (q *Query) ClearWhere() *Query {
+	q.wheres = nil
+	return q
+}
+
+// ClearHaving removes all the `HAVING` conditions.
+func (q *Query) ClearHaving() *Query {
+	q.havings = nil
+	return q
+}
+
+// ClearOrder removes all the `ORDER BY` options.
+func (q *Query) ClearOrder() *Query {
+	q.orders = nil
+	return q
+}
+
+// ClearGroup removes all the `GROUP BY` options including `WithRollup`, `WithCube` and `GroupingSets`.
+func (q *Query) ClearGroup() *Query {
+	q.groups = nil
+	q.groupingSets = nil
+	q.groupModifier = groupModifierNone
+	return q
+}
+
+// ClearSelect removes the selected columns, so every column will be selected.
+func (q *Query) ClearSelect() *Query {
+	q.selects = nil
+	return q
+}
+
+// ReplaceSelect replaces the selected columns without changing the query type.
+func (q *Query) ReplaceSelect(columns ...interface{}) *Query {
+	q.selects = columns
+	return q
+}
+
+// ClearJoins removes all the table joins.
+func (q *Query) ClearJoins() *Query {
+	q.joins = nil
+	return q
+}
+
+// RemoveJoin removes the table joins of the table name or the sub query.
+func (q *Query) RemoveJoin(table interface{}) *Query {
+	var joins []join
+	for _, v := range q.joins {
+		if v.table == table || (v.subQuery != nil && v.subQuery == table) {
+			continue
+		}
+		joins = append(joins, v)
+	}
+	q.joins = joins
+	return q
+}
+
+// ClearUnions removes all the `UNION` queries.
+func (q *Query) ClearUnions() *Query {
+	q.unions = nil
+	return q
+}
+
+// ClearOptions removes all the query options that were set by `SetQueryOption` or the typed options (e.g. `SelectOptions`),
+// the lock is removed as well since `SetQueryOption` sets the lock for the `FOR UPDATE` options.
+func (q *Query) ClearOptions() *Query {
+	q.queryOptions = nil
+	q.lock = nil
+	return q
+}
+
+// ClearLock removes the lock that was set by `ForUpdate`, `ForShare`, `LockInShareMode` or `SetQueryOption`.
+func (q *Query) ClearLock() *Query {
+	q.lock = nil
+	return q
+}
+
+// ResetType resets the query type and removes the data, so the query could be turned into another type of query
+// (e.g. a `SELECT` query that reuses the conditions of an `UPDATE` query).
+func (q *Query) ResetType() *Query {
+	q.typ = QueryTypeUnknown
+	q.data = nil
+	q.duplicate = nil
+	q.assignments = nil
+	q.batchKey = ""
+	q.subQuery = nil
+	return q
+}
+
+// Merge appends the `WHERE` and `HAVING` conditions, and the table joins of the other query to the current query,
+// so the reusable filters could be composed. The conditions are connected with `AND`, and they will be grouped in the parentheses if there's any `OR` condition.
+func (q *Query) Merge(other *Query) *Query {
+	if other.err != nil {
+		q.setErr(other.err)
+	}
+	q.wheres = mergeConditions(q.wheres, other.wheres)
+	q.havings = mergeConditions(q.havings, other.havings)
+	for _, v := range other.joins {
+		v.table = cloneValue(v.table)
+		v.subQuery = v.subQuery.Clone()
+		v.conditions = cloneConditions(v.conditions)
+		q.joins = append(q.joins, v)
+	}
+	return q
+}
+
 // Having creates a `HAVING` condition.
+// Pass a `Named` as the only argument to use the named parameters (e.g. `:id`) in the condition.
 func (q *Query) Having(query string, args ...interface{}) *Query {
 	query, args = q.processEscaped(query, args...)
 	q.havings = append(q.havings, condition{
@@ -208,6 +434,7 @@ func (q *Query) OrHaving(query string, args ...interface{}) *Query {
 }
 
 // Where creates a `WHERE` condition.
+// Pass a `Named` as the only argument to use the named parameters (e.g. `:id`) in the condition.
 func (q *Query) Where(query string, args ...interface{}) *Query {
 	query, args = q.processEscaped(query, args...)
 	q.wheres = append(q.wheres, condition{
@@ -229,6 +456,25 @@ func (q *Query) OrWhere(query string, args ...interface{}) *Query {
 	return q
 }
 
+// WhereIn creates a row-value `IN` condition for the composite columns (e.g. `(TenantID, OrderNo) IN ((?, ?), (?, ?))`),
+// the tuples could be a `[][]interface{}`, a `[]H` or a struct slice, the values will be picked by the columns.
+func (q *Query) WhereIn(columns []string, tuples interface{}) *Query {
+	q.wheres = append(q.wheres, condition{
+		tuple:     &tuple{columns: columns, values: tuples},
+		connector: connectorTypeAnd,
+	})
+	return q
+}
+
+// OrWhereIn creates a row-value `IN` condition like `WhereIn` does but connected with `OR`.
+func (q *Query) OrWhereIn(columns []string, tuples interface{}) *Query {
+	q.wheres = append(q.wheres, condition{
+		tuple:     &tuple{columns: columns, values: tuples},
+		connector: connectorTypeOr,
+	})
+	return q
+}
+
 // JoinWhere creates the `AND` joining condition for latest table join.
 func (q *Query) JoinWhere(query string, args ...interface{}) *Query {
 	query, args = q.processEscaped(query, args...)
@@ -253,8 +499,7 @@ func (q *Query) OrJoinWhere(query string, args ...interface{}) *Query {
 
 // Distinct adds the `DISTINCT` option to the query.
 func (q *Query) Distinct() *Query {
-	q.SetQueryOption("DISTINCT")
-	return q
+	return q.SelectOptions(SelectDistinct)
 }
 
 // Union creates a `UNION` query that connects two tables.
@@ -277,6 +522,7 @@ func (q *Query) UnionAll(qu *Query) *Query {
 }
 
 // OrderBy creates a `ORDER BY` option to the query.
+// The strings will be used as-is (e.g. `ID ASC`) unless `StrictIdentifiers` was enabled, use `OrderByColumns` to be explicit.
 func (q *Query) OrderBy(columns ...string) *Query {
 	for _, v := range columns {
 		q.orders = append(q.orders, order{
@@ -286,21 +532,111 @@ func (q *Query) OrderBy(columns ...string) *Query {
 	return q
 }
 
-// OrderByField creates a `ORDER BY FIELD` option to the query.
+// OrderByColumns creates a `ORDER BY` option like `OrderBy` does, but the columns could be `Ident`, `Raw` or `Col` as well.
+func (q *Query) OrderByColumns(columns ...interface{}) *Query {
+	for _, v := range columns {
+		q.orders = append(q.orders, order{
+			column: v,
+		})
+	}
+	return q
+}
+
+// OrderByAsc creates a `ORDER BY ASC` option to the query, the string column will be treated as an identifier.
+func (q *Query) OrderByAsc(column interface{}) *Query {
+	return q.putOrder(column, OrderAsc)
+}
+
+// OrderByDesc creates a `ORDER BY DESC` option to the query, the string column will be treated as an identifier.
+func (q *Query) OrderByDesc(column interface{}) *Query {
+	return q.putOrder(column, OrderDesc)
+}
+
+// OrderByExpr creates a `ORDER BY` option with an expression, the params of the expression will be bound.
+func (q *Query) OrderByExpr(expr *Expr, direction ...OrderDirection) *Query {
+	var d OrderDirection
+	if len(direction) > 0 {
+		d = direction[0]
+	}
+	return q.putOrder(expr, d)
+}
+
+// OrderByField creates a `ORDER BY FIELD` option to the query, the field will be treated as an identifier.
+// Use `OrderByFieldDirection` to specify the direction.
 func (q *Query) OrderByField(field string, values ...interface{}) *Query {
+	return q.OrderByFieldDirection(field, "", values...)
+}
+
+// OrderByFieldDirection creates a `ORDER BY FIELD` option with the direction (e.g. `OrderByFieldDirection("UserGroup", rushia.OrderDesc, "Admin")`),
+// the field will be treated as an identifier. The direction must be `OrderAsc`, `OrderDesc` or empty, otherwise an error will be returned while building.
+func (q *Query) OrderByFieldDirection(field string, direction OrderDirection, values ...interface{}) *Query {
+	switch direction {
+	case "", OrderAsc, OrderDesc:
+	default:
+		q.setErr(fmt.Errorf("rushia: unknown order direction: %s", direction))
+		return q
+	}
 	q.orders = append(q.orders, order{
-		field:  field,
-		values: values,
+		field:     Ident(field),
+		values:    values,
+		direction: direction,
 	})
 	return q
 }
 
-// GroupBy creates a `GROUP BY` option to the query.
+// NullsFirst sorts the `NULL` values first for the latest `ORDER BY` option,
+// it will be emulated with `IS NULL` on MySQL.
+func (q *Query) NullsFirst() *Query {
+	if len(q.orders) != 0 {
+		q.orders[len(q.orders)-1].nulls = nullsTypeFirst
+	}
+	return q
+}
+
+// NullsLast sorts the `NULL` values last for the latest `ORDER BY` option,
+// it will be emulated with `IS NULL` on MySQL.
+func (q *Query) NullsLast() *Query {
+	if len(q.orders) != 0 {
+		q.orders[len(q.orders)-1].nulls = nullsTypeLast
+	}
+	return q
+}
+
+// GroupBy creates a `GROUP BY` option to the query, use `GroupByColumns` to pass `Ident`, `Raw` or `Col`.
 func (q *Query) GroupBy(columns ...string) *Query {
+	for _, v := range columns {
+		q.groups = append(q.groups, v)
+	}
+	return q
+}
+
+// GroupByColumns creates a `GROUP BY` option like `GroupBy` does, but the columns could be `Ident`, `Raw` or `Col` as well.
+func (q *Query) GroupByColumns(columns ...interface{}) *Query {
 	q.groups = append(q.groups, columns...)
 	return q
 }
 
+// WithRollup adds the super-aggregate rows for the `GROUP BY` columns,
+// it will be built as `WITH ROLLUP` on MySQL and `ROLLUP (...)` on PostgreSQL.
+func (q *Query) WithRollup() *Query {
+	q.groupModifier = groupModifierRollup
+	return q
+}
+
+// WithCube adds the super-aggregate rows for all the combinations of the `GROUP BY` columns with `CUBE (...)`,
+// it's not supported by MySQL.
+func (q *Query) WithCube() *Query {
+	q.groupModifier = groupModifierCube
+	return q
+}
+
+// GroupingSets creates the `GROUPING SETS` for the `GROUP BY` option, pass an empty set to group all the rows.
+// It's not supported by MySQL, and it can't be combined with `WithRollup` or `WithCube`.
+func (q *Query) GroupingSets(sets ...[]interface{}) *Query {
+	q.groupingSets = append(q.groupingSets, sets...)
+	return q
+}
+
 // CrossJoin creates a `CROSS JOIN` to join a table.
 func (q *Query) CrossJoin(table interface{}, conditions ...interface{}) *Query {
 	return q.putJoin(joinTypeCross, table, conditions...)
@@ -326,8 +662,17 @@ func (q *Query) NaturalJoin(table interface{}, conditions ...interface{}) *Query
 	return q.putJoin(joinTypeNatural, table, conditions...)
 }
 
-// SetQueryOption sets the query options, and it will be automatically be appended after or before the query.
+// SetQueryOption sets the query option, it will be placed after the statement keyword in the valid order.
+// An unknown option, or an option that is not supported by the statement returns an error while building.
+//
+// Deprecated: Use the typed options (e.g. `SelectOptions`, `InsertIgnore`) and `ForUpdate`, `ForShare` instead.
 func (q *Query) SetQueryOption(option string) *Query {
-	q.queryOptions = append(q.queryOptions, option)
+	switch option {
+	case "FOR UPDATE":
+		return q.ForUpdate()
+	case "LOCK IN SHARE MODE":
+		return q.LockInShareMode()
+	}
+	q.putQueryOption(queryOptionKindAny, option)
 	return q
 }
@@ -3,7 +3,7 @@ package rushia
 import (
 	"fmt"
 	"reflect"
-	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/iancoleman/strcase"
@@ -26,6 +26,8 @@ type bindOptions struct {
 	// keepStringValue returns the original string value instead of treating it like a prepared statement.
 	// usually used for column names, so it won't be convert to `?` symbol.
 	keepStringValue bool
+	// withAlias appends the alias of the aggregate functions, usually used for the selected columns.
+	withAlias bool
 }
 
 // bindParams loops the bindParam function for each value in the slice, and the values will be bind into the Query.
@@ -42,16 +44,24 @@ func (q *Query) bindParams(data []interface{}, options *bindOptions) string {
 func (q *Query) bindParam(data interface{}, options *bindOptions) string {
 	switch v := data.(type) {
 	case *Query:
-		qu, p := Build(v)
+		qu, p := q.buildSubQuery(v)
 		q.params = append(q.params, p...)
 		if options != nil && options.noParentheses {
 			return qu
 		}
 		return fmt.Sprintf("(%s)", qu)
 	case *Expr:
-		exprQ, exprP := buildExpr(v)
-		q.params = append(q.params, exprP...)
-		return exprQ
+		return q.buildExpr(v)
+	case *Expression:
+		return q.buildExpression(v, options != nil && options.withAlias)
+	case Ident:
+		return quoteIdent(string(v))
+	case Raw:
+		return string(v)
+	case *Column:
+		return v.toQuery()
+	case *Aggregate:
+		return q.buildAggregate(v, options != nil && options.withAlias)
 	case nil:
 		return "NULL"
 	case string:
@@ -61,7 +71,7 @@ func (q *Query) bindParam(data interface{}, options *bindOptions) string {
 		q.params = append(q.params, data)
 		return "?"
 	default:
-		q.params = append(q.params, data)
+		q.params = append(q.params, encodeValue(data))
 		return "?"
 	}
 }
@@ -92,7 +102,31 @@ func (q *Query) separatePairs(h H) string {
 	return q.trim(qu)
 }
 
+// separateAssignments binds the values as the pairs with the assignments (e.g. `Increment`) appended,
+// the columns in the data that were assigned will be overridden.
+func (q *Query) separateAssignments(h H) string {
+	data := make(H, len(h))
+	for k, v := range h {
+		data[k] = v
+	}
+	for _, v := range q.assignments {
+		delete(data, v.column)
+	}
+	qu := q.separatePairs(data)
+	for _, v := range q.assignments {
+		if qu != "" {
+			qu += ", "
+		}
+		qu += fmt.Sprintf("%s = %s", q.escapeCol(v.column), q.bindParam(v.value, nil))
+	}
+	return qu
+}
+
+// escapeCol quotes the column name, it will be used as-is if it looks like an expression unless `StrictIdentifiers` was enabled.
 func (q *Query) escapeCol(v string) string {
+	if StrictIdentifiers {
+		return quoteIdent(v)
+	}
 	// Ignore if `table.column`
 	if strings.Contains(v, ".") || strings.Contains(v, " ") || strings.Contains(v, "(") {
 		return v
@@ -123,49 +157,56 @@ func (q *Query) padSpace(s string) string {
 
 func (q *Query) buildQuery() string {
 	switch q.typ {
-	case queryTypeInsert:
+	case QueryTypeInsert:
 		return q.buildInsert(insertTypeInsert)
-	case queryTypeReplace:
+	case QueryTypeReplace:
 		return q.buildReplace()
-	case queryTypeUpdate:
+	case QueryTypeUpdate:
 		return q.buildUpdate(false)
-	case queryTypeSelect:
+	case QueryTypeSelect:
 		return q.buildSelect()
-	case queryTypePatch:
+	case QueryTypePatch:
 		return q.buildPatch()
-	case queryTypeExists:
+	case QueryTypeExists:
 		return q.buildExists()
-	case queryTypeInsertSelect:
+	case QueryTypeInsertSelect:
 		return q.buildInsertSelect()
-	case queryTypeRawQuery:
+	case QueryTypeRawQuery:
 		return q.buildRawQuery()
-	case queryTypeDelete:
+	case QueryTypeDelete:
 		return q.buildDelete()
 	default:
 		return q.buildNothing()
 	}
 }
 
-func buildExpr(expr *Expr) (query string, params []interface{}) {
+// buildExpr builds the expression and binds the parameters to the query in order,
+// the sub queries and the nested expressions will be built in place.
+func (q *Query) buildExpr(expr *Expr) string {
+	if expr.err != nil {
+		panic(expr.err)
+	}
+	tokens := filterTokens(lex(expr.rawQuery, q.dialect), tokenTypePlaceholder)
+	replacements := make([]string, len(expr.params))
 	for i, j := range expr.params {
-		switch v := j.(type) {
+		switch j.(type) {
 		case *Query:
-			q, p := Build(v)
-			expr.rawQuery = replaceNth(expr.rawQuery, "?", q, i+1)
-			params = append(params, p...)
+			replacements[i] = q.bindParam(j, &bindOptions{noParentheses: true})
+		case *Expr, *Expression, *Aggregate:
+			replacements[i] = q.bindParam(j, nil)
 		default:
-			params = append(params, j)
+			replacements[i] = "?"
+			q.params = append(q.params, encodeValue(j))
 		}
 	}
-	query = expr.rawQuery
-	return
+	return replaceTokens(expr.rawQuery, tokens, replacements)
 }
 
 func (q *Query) buildInsert(typ insertType) string {
 	columns, values, _ := q.explodeData(q.data, []string{})
 
 	insertQuery := typ.toQuery()
-	beforeQuery := q.padSpace(q.trim(q.buildBeforeQueryOptions()))
+	beforeQuery := q.buildBeforeQuery()
 	tableQuery := q.bindParam(q.table, &bindOptions{
 		keepStringValue: true,
 	})
@@ -186,16 +227,19 @@ func (q *Query) buildReplace() string {
 }
 
 func (q *Query) buildUpdate(isPatch bool) string {
+	if q.batchKey != "" {
+		return q.buildUpdateBatch()
+	}
 	_, _, h := q.explodeData(q.data, []string{})
 	data := h[0]
 	if isPatch {
 		data = q.patchH(data)
 	}
-	beforeQuery := q.padSpace(q.trim(q.buildBeforeQueryOptions()))
+	beforeQuery := q.buildBeforeQuery()
 	tableQuery := q.bindParam(q.table, &bindOptions{
 		keepStringValue: true,
-	})
-	pairsQuery := q.separatePairs(data)
+	}) + q.buildIndexHints(q.indexHints)
+	pairsQuery := q.separateAssignments(data)
 
 	return fmt.Sprintf("UPDATE %s%s SET %s",
 		beforeQuery,
@@ -204,11 +248,79 @@ func (q *Query) buildUpdate(isPatch bool) string {
 	)
 }
 
+// buildUpdateBatch builds the `UPDATE` query that updates the rows by the key column with the different values.
+func (q *Query) buildUpdateBatch() string {
+	_, _, datas := q.explodeData(q.data, []string{})
+	if len(datas) == 0 {
+		panic("rushia: no rows were passed to UpdateBatch")
+	}
+	keys := make([]interface{}, len(datas))
+	columnSet := make(map[string]bool)
+	for i, v := range datas {
+		key, ok := v[q.batchKey]
+		if !ok {
+			panic(fmt.Sprintf("rushia: the row of UpdateBatch has no key column: %s", q.batchKey))
+		}
+		keys[i] = key
+		for k := range v {
+			if k != q.batchKey {
+				columnSet[k] = true
+			}
+		}
+	}
+	// The assignments (e.g. `Increment`) replace the columns of the rows.
+	for _, v := range q.assignments {
+		delete(columnSet, v.column)
+	}
+	if len(columnSet) == 0 && len(q.assignments) == 0 {
+		panic("rushia: the rows of UpdateBatch have no column to update except the key column")
+	}
+	columns := make([]string, 0, len(columnSet))
+	for k := range columnSet {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+
+	beforeQuery := q.buildBeforeQuery()
+	tableQuery := q.bindParam(q.table, &bindOptions{
+		keepStringValue: true,
+	}) + q.buildIndexHints(q.indexHints)
+
+	// The `CASE` form is used in PostgreSQL as well, the parameters are typed by the columns
+	// while the `UPDATE ... FROM (VALUES ...)` form leaves them as the text.
+	// UPDATE `Products` SET `Price` = CASE `ID` WHEN ? THEN ? WHEN ? THEN ? ELSE `Price` END WHERE `ID` IN (?, ?)
+	var pairs []string
+	for _, c := range columns {
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("%s = CASE %s", q.escapeCol(c), q.escapeCol(q.batchKey)))
+		for i, v := range datas {
+			// Keep the original value if the row doesn't contain the column.
+			value, ok := v[c]
+			if !ok {
+				continue
+			}
+			b.WriteString(fmt.Sprintf(" WHEN %s THEN %s", q.bindParam(keys[i], nil), q.bindParam(value, nil)))
+		}
+		b.WriteString(fmt.Sprintf(" ELSE %s END", q.escapeCol(c)))
+		pairs = append(pairs, b.String())
+	}
+	for _, v := range q.assignments {
+		pairs = append(pairs, fmt.Sprintf("%s = %s", q.escapeCol(v.column), q.bindParam(v.value, nil)))
+	}
+	q.whereAll("?? IN ?", q.batchKey, keys)
+	return fmt.Sprintf("UPDATE %s%s SET %s",
+		beforeQuery,
+		tableQuery,
+		strings.Join(pairs, ", "),
+	)
+}
+
 func (q *Query) buildDelete() string {
+	beforeQuery := q.buildBeforeQuery()
 	tableQuery := q.bindParam(q.table, &bindOptions{
 		keepStringValue: true,
 	})
-	return fmt.Sprintf("DELETE FROM %s", tableQuery)
+	return fmt.Sprintf("DELETE %sFROM %s", beforeQuery, tableQuery)
 }
 
 func (q *Query) buildNothing() string {
@@ -219,12 +331,12 @@ func (q *Query) buildNothing() string {
 }
 
 func (q *Query) buildSelect() string {
-	beforeQuery := q.padSpace(q.trim(q.buildBeforeQueryOptions()))
+	beforeQuery := q.buildBeforeQuery()
 	selectQuery := "*"
 	if len(q.selects) != 0 {
-		selectQuery = q.bindParams(q.selects, &bindOptions{keepStringValue: true})
+		selectQuery = q.bindParams(q.selects, &bindOptions{keepStringValue: true, withAlias: true})
 	}
-	tableQuery := q.bindParam(q.table, &bindOptions{keepStringValue: true})
+	tableQuery := q.bindParam(q.table, &bindOptions{keepStringValue: true}) + q.buildIndexHints(q.indexHints)
 
 	return fmt.Sprintf("SELECT %s%s FROM %s", beforeQuery, selectQuery, tableQuery)
 }
@@ -233,21 +345,30 @@ func (q *Query) buildPatch() string {
 	return q.buildUpdate(true)
 }
 
+// buildSubQuery builds the sub query with the dialect of the current query,
+// the identifiers and the placeholders are left as-is so they will be converted with the outer query.
+func (q *Query) buildSubQuery(sub *Query) (string, []interface{}) {
+	return build(sub.Copy().SetDialect(q.dialect))
+}
+
 func (q *Query) buildExists() string {
-	query, params := Build(NewRawQuery("SELECT EXISTS(?)", q.Copy().Select()))
+	// The comments and the explain are placed in the outer query only.
+	sub := q.Copy().Select()
+	sub.comments, sub.tags, sub.explain = nil, nil, nil
+	query, params := q.buildSubQuery(NewRawQuery("SELECT EXISTS(?)", sub))
 	q.params = params
 	return query
 }
 
 func (q *Query) buildInsertSelect() string {
-	beforeQuery := q.padSpace(q.trim(q.buildBeforeQueryOptions()))
+	beforeQuery := q.buildBeforeQuery()
 	tableQuery := q.bindParam(q.table, &bindOptions{
 		keepStringValue: true,
 	})
 	fieldQuery := q.bindParams(q.selects, &bindOptions{
 		keepStringValue: true,
 	})
-	selectQuery, selectParams := Build(q.subQuery)
+	selectQuery, selectParams := q.buildSubQuery(q.subQuery)
 	q.bindParams(selectParams, nil)
 
 	return fmt.Sprintf("INSERT %sINTO %s (%s) %s",
@@ -259,9 +380,9 @@ func (q *Query) buildInsertSelect() string {
 }
 
 func (q *Query) buildRawQuery() string {
-	query, params := buildExpr(NewExpr(q.rawQuery, q.params...))
-	q.params = params
-	return query
+	params := q.params
+	q.params = nil
+	return q.buildExpr(NewExpr(q.rawQuery, params...))
 }
 
 func (q *Query) buildUnion() string {
@@ -270,7 +391,7 @@ func (q *Query) buildUnion() string {
 	}
 	var unionQuery string
 	for _, v := range q.unions {
-		query, params := Build(v.query)
+		query, params := q.buildSubQuery(v.query)
 		q.bindParams(params, nil)
 		if v.all {
 			unionQuery += fmt.Sprintf("UNION ALL %s", query)
@@ -289,10 +410,12 @@ func (q *Query) buildAs() string {
 }
 
 func (q *Query) buildDuplicate() string {
-	if q.duplicate == nil {
+	// The assignments belong to the `SET` list of an `UPDATE` query.
+	isUpdate := q.typ == QueryTypeUpdate || q.typ == QueryTypePatch
+	if isUpdate || (q.duplicate == nil && len(q.assignments) == 0) {
 		return ""
 	}
-	duplicateQuery := q.separatePairs(q.duplicate)
+	duplicateQuery := q.separateAssignments(q.duplicate)
 	return fmt.Sprintf("ON DUPLICATE KEY UPDATE %s", duplicateQuery)
 }
 
@@ -306,18 +429,15 @@ func (q *Query) buildJoin() string {
 			table = q.bindParam(v.subQuery, nil)
 
 		// .Join("Table", "Column = Column")
-		case v.table != "":
-			table = q.escapeCol(v.table)
+		case v.table != nil:
+			table = q.bindParam(v.table, &bindOptions{keepStringValue: true})
 		}
+		table += q.buildIndexHints(v.indexHints)
 		jqu += fmt.Sprintf("%s %s ON (%s) ", v.typ.toQuery(), table, q.buildConditions(v.conditions))
 	}
 	return q.trim(jqu)
 }
 
-func removeIndex(s []interface{}, index int) []interface{} {
-	return append(s[:index], s[index+1:]...)
-}
-
 func (q *Query) buildConditions(conditions []condition) string {
 	var qu string
 	for i, condition := range conditions {
@@ -325,45 +445,27 @@ func (q *Query) buildConditions(conditions []condition) string {
 		if i != 0 {
 			qu += fmt.Sprintf("%s ", condition.connector.toQuery())
 		}
+		if len(condition.group) != 0 {
+			qu += fmt.Sprintf("(%s) ", q.buildConditions(condition.group))
+			continue
+		}
+		if condition.tuple != nil {
+			qu += fmt.Sprintf("%s ", q.buildTuple(condition.tuple))
+			continue
+		}
 		if len(condition.args) == 0 {
 			qu += fmt.Sprintf("%s ", condition.query)
 			continue
 		}
 		// ?
-		if !strings.Contains(condition.query, "?") {
+		tokens := filterTokens(lex(condition.query, q.dialect), tokenTypePlaceholder)
+		if len(tokens) == 0 {
 			panic("rushia: incorrect where condition usage")
 		}
-		if strings.Contains(condition.query, "??") {
-			r := regexp.MustCompile(`(?m)(\?\?|\?)`)
-			found := r.FindAllString(condition.query, -1)
-			count := strings.Count(condition.query, "??")
-			for i := len(found) - 1; i >= 0; i-- {
-				if found[i] != "??" {
-					continue
-				}
-				condition.query = replaceNth(condition.query, "??", fmt.Sprintf("`%s`", condition.args[i].(string)), count)
-				count--
-				condition.args = removeIndex(condition.args, i)
-			}
-		}
-
-		//
+		replacements := make([]string, len(condition.args))
 		for argIndex, arg := range condition.args {
-			//
-			if v, ok := arg.(*Query); ok {
-
-				query, params := Build(v)
-				//replacement := query
-				//if strings.Contains(condition.query, "EXISTS") {
-				//	replacement = fmt.Sprintf("(%s)", query)
-				//}
-
-				condition.query = replaceNth(condition.query, "?", fmt.Sprintf("(%s)", query), argIndex+1)
-				q.bindParams(params, nil)
-				continue
-			}
-			//
-			if reflect.TypeOf(arg).Kind() == reflect.Slice {
+			// .Where("ID IN ?", []int{1, 2, 3})
+			if isList(arg) {
 				var params []interface{}
 				s := reflect.ValueOf(arg)
 				if s.Len() == 0 {
@@ -372,32 +474,145 @@ func (q *Query) buildConditions(conditions []condition) string {
 				for i := 0; i < s.Len(); i++ {
 					params = append(params, s.Index(i).Interface())
 				}
-				condition.query = replaceNth(condition.query, "?", fmt.Sprintf("(%s)", q.bindParams(params, nil)), argIndex+1)
+				replacements[argIndex] = fmt.Sprintf("(%s)", q.bindParams(params, nil))
 				continue
 			}
-			q.bindParam(arg, nil)
+			// .Where("ID = ?", 1)
+			// .Where("ID IN ?", subQuery)
+			replacements[argIndex] = q.bindParam(arg, nil)
 		}
-		qu += fmt.Sprintf("%s ", condition.query)
+		qu += fmt.Sprintf("%s ", replaceTokens(condition.query, tokens, replacements))
 	}
 	return q.trim(qu)
 }
 
+// buildTuple builds the row-value `IN` condition, it will be built as `OR` of `AND` conditions if `RowValues` was disabled,
+// and the tuples will be split into multiple `IN` lists if there are more than `TupleChunkSize` tuples.
+func (q *Query) buildTuple(t *tuple) string {
+	tuples := q.explodeTuples(t.columns, t.values)
+	columns := make([]string, len(t.columns))
+	for i, v := range t.columns {
+		columns[i] = quoteIdent(v)
+	}
+
+	// ((`a` = ? AND `b` = ?) OR (`a` = ? AND `b` = ?))
+	if !RowValues {
+		var ors []string
+		for _, v := range tuples {
+			ands := make([]string, len(columns))
+			for i, c := range columns {
+				ands[i] = fmt.Sprintf("%s = %s", c, q.bindParam(v[i], nil))
+			}
+			ors = append(ors, fmt.Sprintf("(%s)", strings.Join(ands, " AND ")))
+		}
+		return fmt.Sprintf("(%s)", strings.Join(ors, " OR "))
+	}
+
+	// (`a`, `b`) IN ((?, ?), (?, ?))
+	size := TupleChunkSize
+	if size <= 0 {
+		size = len(tuples)
+	}
+	var lists []string
+	for i := 0; i < len(tuples); i += size {
+		end := i + size
+		if end > len(tuples) {
+			end = len(tuples)
+		}
+		lists = append(lists, fmt.Sprintf("(%s) IN (%s)", strings.Join(columns, ", "), q.separateGroups(tuples[i:end])))
+	}
+	if len(lists) == 1 {
+		return lists[0]
+	}
+	return fmt.Sprintf("(%s)", strings.Join(lists, " OR "))
+}
+
+// explodeTuples converts the tuples into the values in the order of the columns,
+// a tuple could be a slice of the values, a map or a struct.
+func (q *Query) explodeTuples(columns []string, tuples interface{}) [][]interface{} {
+	s := reflect.ValueOf(tuples)
+	if s.Kind() != reflect.Slice || s.Len() == 0 {
+		panic("rushia: no tuples were passed to WhereIn, stop it before sending to rushia")
+	}
+	result := make([][]interface{}, s.Len())
+	for i := 0; i < s.Len(); i++ {
+		v := s.Index(i)
+		if v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		var values []interface{}
+		switch v.Kind() {
+		// []interface{}{1, "A001"}
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < v.Len(); j++ {
+				values = append(values, v.Index(j).Interface())
+			}
+		// H{"TenantID": 1, "OrderNo": "A001"}, struct
+		default:
+			_, _, datas := q.explodeData(v.Interface(), []string{})
+			for _, c := range columns {
+				value, ok := datas[0][c]
+				if !ok {
+					panic(fmt.Sprintf("rushia: the tuple has no column: %s", c))
+				}
+				values = append(values, value)
+			}
+		}
+		if len(values) != len(columns) {
+			panic("rushia: the tuple length doesn't match the columns")
+		}
+		result[i] = values
+	}
+	return result
+}
+
+// setErr keeps the first error that occurred while creating the query, it will be returned while building.
+func (q *Query) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// processEscaped converts the named parameters, and replaces the `??` symbols with the escaped values.
 func (q *Query) processEscaped(qu string, args ...interface{}) (string, []interface{}) {
-	if !strings.Contains(qu, "??") {
-		return qu, args
-	}
-	r := regexp.MustCompile(`(?m)(\?\?|\?)`)
-	found := r.FindAllString(qu, -1)
-	count := strings.Count(qu, "??")
-	for i := len(found) - 1; i >= 0; i-- {
-		if found[i] != "??" {
+	qu, args, err := escapeArgs(qu, args, q.dialect)
+	if err != nil {
+		q.setErr(err)
+	}
+	return qu, args
+}
+
+// escapeArgs converts the named parameters, and replaces the `??` symbols with the escaped identifiers from the arguments,
+// returns the query and the arguments that were not used as the identifiers.
+func escapeArgs(qu string, args []interface{}, d Dialect) (string, []interface{}, error) {
+	qu, args, err := processNamed(qu, args, d)
+	if err != nil {
+		return qu, args, err
+	}
+	tokens := filterTokens(lex(qu, d), tokenTypePlaceholder, tokenTypeEscape)
+	var (
+		escapes      []token
+		replacements []string
+		result       []interface{}
+	)
+	for i, v := range args {
+		if i >= len(tokens) || tokens[i].typ != tokenTypeEscape {
+			result = append(result, v)
 			continue
 		}
-		qu = replaceNth(qu, "??", fmt.Sprintf("`%s`", args[i].(string)), count)
-		count--
-		args = removeIndex(args, i)
+		var column string
+		switch j := v.(type) {
+		case string:
+			column = j
+		case Ident:
+			column = string(j)
+		default:
+			return qu, args, fmt.Errorf("rushia: the value of the escaped ?? symbol must be a string, got %T", v)
+		}
+		escapes = append(escapes, tokens[i])
+		replacements = append(replacements, quoteIdent(column))
 	}
-	return qu, args
+	return replaceTokens(qu, escapes, replacements), result, nil
 }
 
 func (q *Query) buildWhere() string {
@@ -420,35 +635,96 @@ func (q *Query) buildOrderBy() string {
 	}
 	var qu string
 	for _, v := range q.orders {
-		switch {
-		// .OrderBy("RAND()")
-		// .OrderBy("ID ASC")
-		case v.column != "":
-			qu += fmt.Sprintf("%s, ", v.column)
-
-		// .OrderByField("UserGroup ASC", "SuperUser", "Admin")
-		case v.field != "":
-			qu += fmt.Sprintf("FIELD (%s, %s), ", v.field, q.bindParams(v.values, nil))
+		// MySQL doesn't support `NULLS FIRST`, `NULLS LAST`,
+		// so sort by `IS NULL` first (`false` goes first in ascending order).
+		if v.nulls != nullsTypeDefault && q.dialect == DialectMySQL {
+			nullsQuery := fmt.Sprintf("%s IS NULL", q.buildOrderColumn(v))
+			if v.nulls == nullsTypeFirst {
+				nullsQuery += " DESC"
+			}
+			qu += fmt.Sprintf("%s, ", nullsQuery)
+		}
+		column := q.buildOrderColumn(v)
+		if v.direction != "" {
+			column += fmt.Sprintf(" %s", v.direction)
 		}
+		if v.nulls != nullsTypeDefault && q.dialect != DialectMySQL {
+			column += fmt.Sprintf(" NULLS %s", v.nulls.toQuery())
+		}
+		qu += fmt.Sprintf("%s, ", column)
 	}
 	return fmt.Sprintf("ORDER BY %s", q.trim(qu))
 }
 
+// buildOrderColumn builds the column or the expression of the `ORDER BY` option without the direction.
+func (q *Query) buildOrderColumn(v order) string {
+	switch {
+	// .OrderBy("RAND()")
+	// .OrderBy("ID ASC")
+	case v.column != nil:
+		if s, ok := v.column.(string); ok && !StrictIdentifiers {
+			return s
+		}
+		return q.bindParam(v.column, &bindOptions{keepStringValue: true})
+
+	// .OrderByFieldDirection("UserGroup", OrderAsc, "SuperUser", "Admin")
+	case v.field != nil:
+		if q.dialect == DialectPostgreSQL {
+			panic("rushia: OrderByField is not supported by PostgreSQL, use OrderByExpr instead")
+		}
+		return fmt.Sprintf("FIELD (%s, %s)", q.bindParam(v.field, &bindOptions{keepStringValue: true}), q.bindParams(v.values, nil))
+	}
+	return ""
+}
+
 func (q *Query) buildGroupBy() string {
-	if len(q.groups) == 0 {
+	if len(q.groups) == 0 && len(q.groupingSets) == 0 {
 		return ""
 	}
-	var result string
-	for _, v := range q.groups {
-		result += fmt.Sprintf("%s, ", q.escapeCol(v))
+	if q.groupModifier != groupModifierNone && len(q.groupingSets) != 0 {
+		panic("rushia: WithRollup and WithCube cannot be combined with GroupingSets")
+	}
+	result := q.bindParams(q.groups, &bindOptions{keepStringValue: true})
+
+	switch q.groupModifier {
+	case groupModifierRollup:
+		// GROUP BY `a`, `b` WITH ROLLUP
+		// GROUP BY ROLLUP (`a`, `b`)
+		if q.dialect == DialectMySQL {
+			return fmt.Sprintf("GROUP BY %s WITH ROLLUP", result)
+		}
+		return fmt.Sprintf("GROUP BY ROLLUP (%s)", result)
+	case groupModifierCube:
+		if q.dialect == DialectMySQL {
+			panic("rushia: CUBE is not supported by MySQL")
+		}
+		return fmt.Sprintf("GROUP BY CUBE (%s)", result)
+	}
+
+	if len(q.groupingSets) != 0 {
+		if q.dialect == DialectMySQL {
+			panic("rushia: GROUPING SETS is not supported by MySQL")
+		}
+		var sets []string
+		for _, v := range q.groupingSets {
+			sets = append(sets, fmt.Sprintf("(%s)", q.bindParams(v, &bindOptions{keepStringValue: true})))
+		}
+		if result != "" {
+			result += ", "
+		}
+		result += fmt.Sprintf("GROUPING SETS (%s)", strings.Join(sets, ", "))
 	}
-	return fmt.Sprintf("GROUP BY %s", strings.TrimSuffix(result, ", "))
+	return fmt.Sprintf("GROUP BY %s", result)
 }
 
 func (q *Query) buildLimit() string {
 	if q.limit.from != 0 && q.limit.count == 0 {
 		return fmt.Sprintf("LIMIT %d", q.limit.from)
 	} else if q.limit.count != 0 {
+		// LIMIT 10 OFFSET 5
+		if q.dialect == DialectPostgreSQL {
+			return fmt.Sprintf("LIMIT %d OFFSET %d", q.limit.count, q.limit.from)
+		}
 		return fmt.Sprintf("LIMIT %d, %d", q.limit.from, q.limit.count)
 	} else {
 		return ""
@@ -462,34 +738,42 @@ func (q *Query) buildOffset() string {
 	return fmt.Sprintf("LIMIT %d OFFSET %d", q.offset.count, q.offset.offset)
 }
 
-func (q *Query) buildBeforeQueryOptions() string {
-	var qu string
-	for _, v := range q.queryOptions {
-		switch v {
-		case "ALL", "DISTINCT", "SQL_CACHE", "SQL_NO_CACHE", "DISTINCTROW", "HIGH_PRIORITY", "STRAIGHT_JOIN", "SQL_SMALL_RESULT", "SQL_BIG_RESULT", "SQL_BUFFER_RESULT", "SQL_CALC_FOUND_ROWS", "LOW_PRIORITY", "QUICK", "IGNORE", "DELAYED":
-			qu += fmt.Sprintf("%s, ", v)
-		}
-	}
-	return qu
-}
-
-func (q *Query) buildAfterQueryOptions() string {
-	var qu string
-	for _, v := range q.queryOptions {
-		switch v {
-		case "FOR UPDATE", "LOCK IN SHARE MODE":
-			qu += fmt.Sprintf("%s, ", v)
-		}
-	}
-	return qu
+// buildBeforeQuery builds the optimizer hints and the query options that are placed right after the statement keyword.
+func (q *Query) buildBeforeQuery() string {
+	return q.padSpace(q.buildOptimizerHints()) + q.padSpace(q.buildQueryOptions())
 }
 
 //=======================================================
 // Helpers
 //=======================================================
 
+// checkAssignments returns an error if the assignments (e.g. `Increment`) can't be used in the query, they are only for the `UPDATE` and `INSERT` queries.
+func (q *Query) checkAssignments() error {
+	switch q.typ {
+	case QueryTypeUnknown, QueryTypeUpdate, QueryTypePatch, QueryTypeInsert, QueryTypeInsertSelect:
+		return nil
+	}
+	return fmt.Errorf("rushia: the assignments can only be used in an UPDATE or INSERT query, got %s", q.typ)
+}
+
+// checkFullTable returns ErrFullTable if the query updates or deletes the full table while `RequireWhere` is enabled.
+func (q *Query) checkFullTable() error {
+	if !RequireWhere || q.allowFullTable || len(q.wheres) != 0 || q.batchKey != "" {
+		return nil
+	}
+	switch q.typ {
+	case QueryTypeUpdate, QueryTypePatch, QueryTypeDelete:
+		return ErrFullTable
+	}
+	return nil
+}
+
 func (q *Query) explodeData(data any, preferCols []string) (cols []string, vals [][]any, datas []H) {
 	switch v := data.(type) {
+	// .Increment("Views", 1) without the data.
+	case nil:
+		return q.explodeData(H{}, preferCols)
+
 	case H:
 		val := q.omitH(v)
 		expCols, expVal := q.explodeH(val, preferCols)
@@ -591,18 +875,34 @@ func (q *Query) explodeValue(val reflect.Value) H {
 	t := val.Type()
 
 	for i := 0; i < t.NumField(); i++ {
-		k := strcase.ToSnake(t.Field(i).Name)
-		if name, ok := t.Field(i).Tag.Lookup("rushia"); ok {
-			if name == "" || name == "-" {
-				continue
-			}
-			k = name
+		k, _, ok := parseFieldTag(t.Field(i))
+		if !ok {
+			continue
 		}
 		h[k] = val.Field(i).Interface()
 	}
 	return h
 }
 
+// parseFieldTag returns the column name and the options of a struct field based on the rushia struct tag,
+// the column name could be renamed by `rushia:"name"`, and the options are separated by the commas (e.g. `rushia:"id,pk"`).
+// Returns false if the field should be ignored.
+func parseFieldTag(f reflect.StructField) (column string, options []string, ok bool) {
+	column = strcase.ToSnake(f.Name)
+	tag, exists := f.Tag.Lookup("rushia")
+	if !exists {
+		return column, nil, true
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] == "-" || tag == "" {
+		return "", nil, false
+	}
+	if parts[0] != "" {
+		column = parts[0]
+	}
+	return column, parts[1:], true
+}
+
 // mapsToHs converts map slice to H slice.
 func (q *Query) mapsToHs(data []map[string]interface{}) []H {
 	var hs []H
@@ -640,22 +940,72 @@ func (q *Query) trim(s string) string {
 	return strings.TrimRight(strings.TrimSpace(s), ",")
 }
 
-// replaceNth removes the nth repeated occurrence of the specified string,
-// usually used for sub query prepared statment `?` symbol replacement.
-func replaceNth(s, old, new string, n int) string {
-	i := 0
-	for m := 1; m <= n; m++ {
-		x := strings.Index(s[i:], old)
-		if x < 0 {
-			break
-		}
-		i += x
-		if m == n {
-			return s[:i] + new + s[i+len(old):]
-		}
-		i += len(old)
+// groupWheres groups the existing `WHERE` conditions in the parentheses,
+// so the later `AND` conditions will be applied to all of them.
+func (q *Query) groupWheres() {
+	q.wheres = groupConditions(q.wheres)
+}
+
+// groupConditions wraps the conditions in a group, the raw conditions are always wrapped
+// since they might contain an `OR` (e.g. `Where("a = ? OR b = ?")`) that can't be told from the connectors.
+// The single group or tuple is left as-is because it was built in the parentheses already.
+func groupConditions(conditions []condition) []condition {
+	if len(conditions) == 0 {
+		return conditions
 	}
-	return s
+	if len(conditions) == 1 && (len(conditions[0].group) != 0 || conditions[0].tuple != nil) {
+		return conditions
+	}
+	return []condition{{group: conditions}}
+}
+
+// mergeConditions appends the conditions to the existing conditions with `AND`,
+// both of them will be grouped first so the `OR` conditions won't leak into each other.
+func mergeConditions(conditions []condition, others []condition) []condition {
+	if len(others) == 0 {
+		return conditions
+	}
+	if len(conditions) == 0 {
+		return cloneConditions(others)
+	}
+	others = groupConditions(cloneConditions(others))
+	others[0].connector = connectorTypeAnd
+	return append(groupConditions(conditions), others...)
+}
+
+// whereAll appends a `WHERE` condition that applies to all the existing conditions.
+func (q *Query) whereAll(query string, args ...interface{}) *Query {
+	q.groupWheres()
+	return q.Where(query, args...)
+}
+
+// putAssignment appends a `SET` assignment of an `UPDATE` query, or an `ON DUPLICATE KEY UPDATE` assignment of an `INSERT` query.
+// It turns the query into an `UPDATE` query if the query type was not set.
+func (q *Query) putAssignment(column string, value interface{}) *Query {
+	if q.typ == QueryTypeUnknown {
+		q.typ = QueryTypeUpdate
+	}
+	if err := q.checkAssignments(); err != nil {
+		q.setErr(err)
+		return q
+	}
+	q.assignments = append(q.assignments, assignment{
+		column: column,
+		value:  value,
+	})
+	return q
+}
+
+// putOrder appends a typed `ORDER BY` option, the string column will be treated as an identifier.
+func (q *Query) putOrder(column interface{}, direction OrderDirection) *Query {
+	if v, ok := column.(string); ok {
+		column = Ident(v)
+	}
+	q.orders = append(q.orders, order{
+		column:    column,
+		direction: direction,
+	})
+	return q
 }
 
 // putJoin
@@ -666,14 +1016,15 @@ func (q *Query) putJoin(typ joinType, t interface{}, conditions ...interface{})
 	switch v := t.(type) {
 	case *Query:
 		j.subQuery = v
-	case string:
+	default:
 		j.table = v
 	}
 	if len(conditions) != 0 {
+		query, args := q.processEscaped(conditions[0].(string), conditions[1:]...)
 		j.conditions = []condition{
 			{
-				query: conditions[0].(string),
-				args:  conditions[1:],
+				query: query,
+				args:  args,
 				// It's fine to be `And` or `Or`
 				// since the build doesn't build the first connector.
 				connector: connectorTypeAnd,
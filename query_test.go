@@ -1,6 +1,11 @@
 package rushia
 
 import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net"
 	"reflect"
 	"strings"
 	"testing"
@@ -413,6 +418,101 @@ func TestLimitUpdate(t *testing.T) {
 	assertParams(assert, []interface{}{"Karisu", "123456"}, params)
 }
 
+func TestUpdateIncrement(t *testing.T) {
+	assert := assert.New(t)
+	query, params := Build(NewQuery("Posts").Where("ID = ?", 1).Increment("Views", 1))
+	assert.Equal("UPDATE `Posts` SET `Views` = (`Views` + ?) WHERE ID = ?", query)
+	assertParamOrders(assert, []interface{}{1, 1}, params)
+
+	query, params = Build(NewQuery("Products").Where("ID = ?", 1).Update(H{"Stock": 10}).Decrement("Stock", 3).Min(0).SetColumn("PrevStatus", "Status"))
+	assert.Equal("UPDATE `Products` SET `Stock` = GREATEST((`Stock` - ?), ?), `PrevStatus` = `Status` WHERE ID = ?", query)
+	assertParamOrders(assert, []interface{}{3, 0, 1}, params)
+
+	query, params = Build(NewQuery("Products").Where("ID = ?", 1).Update(H{"Name": "Book"}).Increment("Stock", 5).Max(100).SetExpr("UpdatedAt", NewExpr("NOW()")))
+	assert.Equal("UPDATE `Products` SET `Name` = ?, `Stock` = LEAST((`Stock` + ?), ?), `UpdatedAt` = NOW() WHERE ID = ?", query)
+	assertParamOrders(assert, []interface{}{"Book", 5, 100, 1}, params)
+
+	_, _, err := TryBuild(NewQuery("Products").Where("ID = ?", 1).Min(0))
+	assert.EqualError(err, "rushia: Min requires a prior Increment, Decrement or SetExpr")
+
+	_, _, err = TryBuild(NewQuery("Products").Where("ID = ?", 1).Select().Increment("Views", 1))
+	assert.EqualError(err, "rushia: the assignments can only be used in an UPDATE or INSERT query, got SELECT")
+
+	_, _, err = TryBuild(NewQuery("Products").Where("ID = ?", 1).Increment("Views", 1).Select())
+	assert.EqualError(err, "rushia: the assignments can only be used in an UPDATE or INSERT query, got SELECT")
+}
+
+func TestUpdateIncrementOnDuplicate(t *testing.T) {
+	assert := assert.New(t)
+	query, params := Build(NewQuery("Counters").Insert(H{"Name": "visits"}).Increment("Count", 1))
+	assert.Equal("INSERT INTO `Counters` (`Name`) VALUES (?) ON DUPLICATE KEY UPDATE `Count` = (`Count` + ?)", query)
+	assertParamOrders(assert, []interface{}{"visits", 1}, params)
+
+	query, params = Build(NewQuery("Counters").Insert(H{"Name": "visits"}).OnDuplicate(H{"Name": "visits"}).Increment("Count", 1))
+	assert.Equal("INSERT INTO `Counters` (`Name`) VALUES (?) ON DUPLICATE KEY UPDATE `Name` = ?, `Count` = (`Count` + ?)", query)
+	assertParamOrders(assert, []interface{}{"visits", "visits", 1}, params)
+}
+
+func TestUpdateBatch(t *testing.T) {
+	assert := assert.New(t)
+	rows := []H{
+		{"ID": 1, "Price": 100, "Stock": 5},
+		{"ID": 2, "Price": 200},
+	}
+	query, params := Build(NewQuery("Products").Where("Status = ?", "active").UpdateBatch(rows, "ID"))
+	assert.Equal("UPDATE `Products` SET `Price` = CASE `ID` WHEN ? THEN ? WHEN ? THEN ? ELSE `Price` END, `Stock` = CASE `ID` WHEN ? THEN ? ELSE `Stock` END WHERE (Status = ?) AND `ID` IN (?, ?)", query)
+	assertParamOrders(assert, []interface{}{1, 100, 2, 200, 1, 5, "active", 1, 2}, params)
+
+	type product struct {
+		ID    int
+		Price int
+	}
+	query, params = Build(NewQuery("Products").UpdateBatch([]product{{ID: 1, Price: 100}, {ID: 2, Price: 200}}, "id").SetDialect(DialectPostgreSQL))
+	assert.Equal(`UPDATE "Products" SET "price" = CASE "id" WHEN $1 THEN $2 WHEN $3 THEN $4 ELSE "price" END WHERE "id" IN ($5, $6)`, query)
+	assertParamOrders(assert, []interface{}{1, 100, 2, 200, 1, 2}, params)
+
+	query, params = Build(NewQuery("Products").UpdateBatch(rows, "ID").SetDialect(DialectPostgreSQL))
+	assert.Equal(`UPDATE "Products" SET "Price" = CASE "ID" WHEN $1 THEN $2 WHEN $3 THEN $4 ELSE "Price" END, "Stock" = CASE "ID" WHEN $5 THEN $6 ELSE "Stock" END WHERE "ID" IN ($7, $8)`, query)
+	assertParamOrders(assert, []interface{}{1, 100, 2, 200, 1, 5, 1, 2}, params)
+
+	_, _, err := TryBuild(NewQuery("Products").UpdateBatch([]H{{"Price": 100}}, "ID"))
+	assert.EqualError(err, "rushia: the row of UpdateBatch has no key column: ID")
+
+	query, params = Build(NewQuery("Products").UpdateBatch([]H{{"ID": 1, "Price": 100}, {"ID": 2, "Price": 200}}, "ID").Increment("Views", 1))
+	assert.Equal("UPDATE `Products` SET `Price` = CASE `ID` WHEN ? THEN ? WHEN ? THEN ? ELSE `Price` END, `Views` = (`Views` + ?) WHERE `ID` IN (?, ?)", query)
+	assert.Equal([]interface{}{1, 100, 2, 200, 1, 1, 2}, params)
+
+	query, params = Build(NewQuery("Products").UpdateBatch([]H{{"ID": 1}, {"ID": 2}}, "ID").Increment("Views", 1))
+	assert.Equal("UPDATE `Products` SET `Views` = (`Views` + ?) WHERE `ID` IN (?, ?)", query)
+	assert.Equal([]interface{}{1, 1, 2}, params)
+
+	_, _, err = TryBuild(NewQuery("Products").UpdateBatch([]H{{"ID": 1}, {"ID": 2}}, "ID"))
+	assert.EqualError(err, "rushia: the rows of UpdateBatch have no column to update except the key column")
+
+	// The raw `OR` condition is grouped so it won't match the rows out of the batch.
+	query, _ = Build(NewQuery("Products").Where("a = 1 OR b = 2").UpdateBatch([]H{{"ID": 1, "Price": 100}}, "ID"))
+	assert.Equal("UPDATE `Products` SET `Price` = CASE `ID` WHEN ? THEN ? ELSE `Price` END WHERE (a = 1 OR b = 2) AND `ID` IN (?)", query)
+}
+
+func TestChunk(t *testing.T) {
+	assert := assert.New(t)
+	rows := []H{{"ID": 1, "Price": 100}, {"ID": 2, "Price": 200}, {"ID": 3, "Price": 300}}
+	queries := NewQuery("Products").UpdateBatch(rows, "ID").Chunk(2)
+	assert.Len(queries, 2)
+	query, params := Build(queries[0])
+	assert.Equal("UPDATE `Products` SET `Price` = CASE `ID` WHEN ? THEN ? WHEN ? THEN ? ELSE `Price` END WHERE `ID` IN (?, ?)", query)
+	assertParamOrders(assert, []interface{}{1, 100, 2, 200, 1, 2}, params)
+	query, params = Build(queries[1])
+	assert.Equal("UPDATE `Products` SET `Price` = CASE `ID` WHEN ? THEN ? ELSE `Price` END WHERE `ID` IN (?)", query)
+	assertParamOrders(assert, []interface{}{3, 300, 3}, params)
+
+	queries = NewQuery("Users").Insert([]H{{"Name": "A"}, {"Name": "B"}, {"Name": "C"}}).Chunk(2)
+	assert.Len(queries, 2)
+	query, params = Build(queries[1])
+	assert.Equal("INSERT INTO `Users` (`Name`) VALUES (?)", query)
+	assertParamOrders(assert, []interface{}{"C"}, params)
+}
+
 //=======================================================
 // Patch
 //=======================================================
@@ -755,6 +855,108 @@ func TestRawWhere(t *testing.T) {
 	assertParams(assert, []interface{}{"YamiOdymel"}, params)
 }
 
+func TestWhereInTuple(t *testing.T) {
+	assert := assert.New(t)
+	q := NewQuery("Orders").Where("Status = ?", "paid").WhereIn([]string{"TenantID", "OrderNo"}, [][]interface{}{{1, "A001"}, {2, "B002"}}).Select()
+	query, params := Build(q)
+	assert.Equal("SELECT * FROM `Orders` WHERE Status = ? AND (`TenantID`, `OrderNo`) IN ((?, ?), (?, ?))", query)
+	assertParamOrders(assert, []interface{}{"paid", 1, "A001", 2, "B002"}, params)
+	assert.Equal([]string{"TenantID", "OrderNo"}, q.Conditions()[1].TupleColumns)
+
+	type key struct {
+		TenantID int    `rushia:"TenantID"`
+		OrderNo  string `rushia:"OrderNo"`
+		Note     string
+	}
+	query, params = Build(NewQuery("Orders").Where("Status = ?", "paid").OrWhereIn([]string{"TenantID", "OrderNo"}, []key{{1, "A001", "x"}}).Select())
+	assert.Equal("SELECT * FROM `Orders` WHERE Status = ? OR (`TenantID`, `OrderNo`) IN ((?, ?))", query)
+	assertParamOrders(assert, []interface{}{"paid", 1, "A001"}, params)
+
+	query, params = Build(NewQuery("Orders").WhereIn([]string{"TenantID", "OrderNo"}, []H{{"TenantID": 1, "OrderNo": "A001"}}).Select())
+	assert.Equal("SELECT * FROM `Orders` WHERE (`TenantID`, `OrderNo`) IN ((?, ?))", query)
+	assertParamOrders(assert, []interface{}{1, "A001"}, params)
+
+	_, _, err := TryBuild(NewQuery("Orders").WhereIn([]string{"TenantID", "OrderNo"}, [][]interface{}{{1}}).Select())
+	assert.EqualError(err, "rushia: the tuple length doesn't match the columns")
+	_, _, err = TryBuild(NewQuery("Orders").WhereIn([]string{"TenantID", "OrderNo"}, [][]interface{}{}).Select())
+	assert.EqualError(err, "rushia: no tuples were passed to WhereIn, stop it before sending to rushia")
+}
+
+func TestWhereInTupleFallback(t *testing.T) {
+	assert := assert.New(t)
+	RowValues = false
+	t.Cleanup(func() { RowValues = true })
+	query, params := Build(NewQuery("Orders").WhereIn([]string{"TenantID", "OrderNo"}, [][]interface{}{{1, "A001"}, {2, "B002"}}).Select())
+	assert.Equal("SELECT * FROM `Orders` WHERE ((`TenantID` = ? AND `OrderNo` = ?) OR (`TenantID` = ? AND `OrderNo` = ?))", query)
+	assertParamOrders(assert, []interface{}{1, "A001", 2, "B002"}, params)
+}
+
+func TestWhereInTupleChunk(t *testing.T) {
+	assert := assert.New(t)
+	TupleChunkSize = 2
+	t.Cleanup(func() { TupleChunkSize = 1000 })
+	query, params := Build(NewQuery("Orders").WhereIn([]string{"TenantID", "OrderNo"}, [][]interface{}{{1, "A"}, {2, "B"}, {3, "C"}}).Select())
+	assert.Equal("SELECT * FROM `Orders` WHERE ((`TenantID`, `OrderNo`) IN ((?, ?), (?, ?)) OR (`TenantID`, `OrderNo`) IN ((?, ?)))", query)
+	assertParamOrders(assert, []interface{}{1, "A", 2, "B", 3, "C"}, params)
+}
+
+type testStringArray []string
+
+func (a testStringArray) Value() (driver.Value, error) {
+	return "{" + strings.Join(a, ",") + "}", nil
+}
+
+type testStatus int
+
+func TestWhereSingleValueSlice(t *testing.T) {
+	assert := assert.New(t)
+	hash := []byte{0xDE, 0xAD}
+	query, params := Build(NewQuery("Users").Where("Hash = ?", hash).Select())
+	assert.Equal("SELECT * FROM `Users` WHERE Hash = ?", query)
+	assert.Equal([]interface{}{hash}, params)
+
+	ip := net.ParseIP("127.0.0.1")
+	raw := json.RawMessage(`{"a":1}`)
+	query, params = Build(NewQuery("Users").Where("IP = ? AND Data = ?", ip, raw).Select())
+	assert.Equal("SELECT * FROM `Users` WHERE IP = ? AND Data = ?", query)
+	assert.Equal([]interface{}{ip, raw}, params)
+
+	tags := testStringArray{"a", "b"}
+	query, params = Build(NewQuery("Users").Where("Tags = ?", tags).Select())
+	assert.Equal("SELECT * FROM `Users` WHERE Tags = ?", query)
+	assert.Equal([]interface{}{tags}, params)
+
+	assert.Equal("SELECT * FROM `Users` WHERE Hash = X'dead'", NewQuery("Users").Where("Hash = ?", hash).Select().ToSQL())
+}
+
+func TestEncoder(t *testing.T) {
+	assert := assert.New(t)
+	t.Cleanup(ClearEncoders)
+	RegisterEncoder(testStatus(0), func(v interface{}) (interface{}, error) {
+		switch v.(testStatus) {
+		case 1:
+			return "active", nil
+		case 2:
+			return "banned", nil
+		}
+		return nil, errors.New("unknown status")
+	})
+	RegisterEncoder(time.Time{}, func(v interface{}) (interface{}, error) {
+		return v.(time.Time).UTC(), nil
+	})
+	local := time.Date(2026, 1, 1, 8, 0, 0, 0, time.FixedZone("UTC+8", 8*60*60))
+	query, params := Build(NewQuery("Users").Where("Status IN ? AND CreatedAt > ?", []testStatus{1, 2}, local).Update(H{"Status": testStatus(2)}))
+	assertEqual(assert, "UPDATE `Users` SET `Status` = ? WHERE Status IN (?, ?) AND CreatedAt > ?", query)
+	assertParamOrders(assert, []interface{}{"banned", "active", "banned", local.UTC()}, params)
+	assert.Equal(time.UTC, params[3].(time.Time).Location())
+
+	_, params = Build(NewRawQuery("SELECT * FROM Users WHERE Status = ?", testStatus(1)))
+	assert.Equal([]interface{}{"active"}, params)
+
+	_, _, err := TryBuild(NewQuery("Users").Where("Status = ?", testStatus(3)).Select())
+	assert.EqualError(err, "rushia: failed to encode the value of rushia.testStatus: unknown status")
+}
+
 //=======================================================
 // As
 //=======================================================
@@ -833,6 +1035,35 @@ func TestDelete(t *testing.T) {
 	assertParams(assert, []interface{}{1}, params)
 }
 
+func TestRequireWhere(t *testing.T) {
+	assert := assert.New(t)
+	RequireWhere = true
+	t.Cleanup(func() {
+		RequireWhere = false
+	})
+
+	_, _, err := TryBuild(NewQuery("Users").Delete())
+	assert.ErrorIs(err, ErrFullTable)
+	_, _, err = TryBuild(NewQuery("Users").Update(H{"Username": "YamiOdymel"}))
+	assert.ErrorIs(err, ErrFullTable)
+	_, _, err = TryBuild(NewQuery("Users").Patch(H{"Username": "YamiOdymel"}))
+	assert.ErrorIs(err, ErrFullTable)
+	assert.Panics(func() {
+		Build(NewQuery("Users").Delete())
+	})
+
+	query, _, err := TryBuild(NewQuery("Users").AllowFullTable().Delete())
+	assert.NoError(err)
+	assertEqual(assert, "DELETE FROM `Users`", query)
+
+	query, _, err = TryBuild(NewQuery("Users").Where("ID = ?", 1).Delete())
+	assert.NoError(err)
+	assertEqual(assert, "DELETE FROM `Users` WHERE ID = ?", query)
+
+	_, _, err = TryBuild(NewQuery("Users").Select())
+	assert.NoError(err)
+}
+
 //=======================================================
 // OrderBy
 //=======================================================
@@ -846,8 +1077,46 @@ func TestOrderBy(t *testing.T) {
 func TestOrderByField(t *testing.T) {
 	assert := assert.New(t)
 	query, params := Build(NewQuery("Users").OrderByField("UserGroup", "SuperUser", "Admin", "Users").Select())
-	assertEqual(assert, "SELECT * FROM `Users` ORDER BY FIELD (UserGroup, ?, ?, ?)", query)
+	assertEqual(assert, "SELECT * FROM `Users` ORDER BY FIELD (`UserGroup`, ?, ?, ?)", query)
 	assertParams(assert, []interface{}{"SuperUser", "Admin", "Users"}, params)
+
+	_, _, err := TryBuild(NewQuery("Users").OrderByFieldDirection("UserGroup", "SuperUser", "Admin").Select())
+	assert.EqualError(err, "rushia: unknown order direction: SuperUser")
+}
+
+func TestOrderByDirection(t *testing.T) {
+	assert := assert.New(t)
+	q := NewQuery("Users").
+		OrderByDesc("users.created_at").
+		OrderByAsc(Raw("RAND()")).
+		OrderByExpr(NewExpr("ABS(Score - ?)", 100), OrderDesc).
+		OrderByFieldDirection("UserGroup", OrderDesc, "Admin", "User").
+		Select()
+	query, params := Build(q)
+	assertEqual(assert, "SELECT * FROM `Users` ORDER BY `users`.`created_at` DESC, RAND() ASC, ABS(Score - ?) DESC, FIELD (`UserGroup`, ?, ?) DESC", query)
+	assertParamOrders(assert, []interface{}{100, "Admin", "User"}, params)
+
+	assert.Equal([]Order{
+		{Column: Ident("users.created_at"), Values: []interface{}{}, Direction: OrderDesc},
+		{Column: Raw("RAND()"), Values: []interface{}{}, Direction: OrderAsc},
+		{Column: NewExpr("ABS(Score - ?)", 100), Values: []interface{}{}, Direction: OrderDesc},
+		{Column: Ident("UserGroup"), Values: []interface{}{"Admin", "User"}, Direction: OrderDesc},
+	}, q.Orders())
+}
+
+func TestOrderByNulls(t *testing.T) {
+	assert := assert.New(t)
+	q := NewQuery("Users").
+		OrderByDesc("LastLogin").NullsLast().
+		OrderByExpr(NewExpr("ABS(Score - ?)", 100)).NullsFirst().
+		Select()
+	query, params := Build(q)
+	assertEqual(assert, "SELECT * FROM `Users` ORDER BY `LastLogin` IS NULL, `LastLogin` DESC, ABS(Score - ?) IS NULL DESC, ABS(Score - ?)", query)
+	assertParamOrders(assert, []interface{}{100, 100}, params)
+
+	query, params = Build(q.Copy().SetDialect(DialectPostgreSQL))
+	assertEqual(assert, "SELECT * FROM \"Users\" ORDER BY \"LastLogin\" DESC NULLS LAST, ABS(Score - $1) NULLS FIRST", query)
+	assertParamOrders(assert, []interface{}{100}, params)
 }
 
 //=======================================================
@@ -862,6 +1131,63 @@ func TestGroupBy(t *testing.T) {
 	assertEqual(assert, "SELECT * FROM `Users` GROUP BY `Name`, `ID`", query)
 }
 
+func TestGroupByRollup(t *testing.T) {
+	assert := assert.New(t)
+	q := NewQuery("Sales").GroupBy("Year", "Country").WithRollup().Select("Year", "Country", Grouping("Country").As("IsTotal"), Sum("Profit"))
+	query, _ := Build(q)
+	assert.Equal("SELECT `Year`, `Country`, GROUPING(`Country`) AS `IsTotal`, SUM(`Profit`) FROM `Sales` GROUP BY `Year`, `Country` WITH ROLLUP", query)
+	query, _ = Build(q.Copy().SetDialect(DialectPostgreSQL))
+	assert.Equal("SELECT \"Year\", \"Country\", GROUPING(\"Country\") AS \"IsTotal\", SUM(\"Profit\") FROM \"Sales\" GROUP BY ROLLUP (\"Year\", \"Country\")", query)
+}
+
+func TestGroupByCubeAndGroupingSets(t *testing.T) {
+	assert := assert.New(t)
+	query, _ := Build(NewQuery("Sales").GroupBy("Year", "Country").WithCube().SetDialect(DialectPostgreSQL).Select())
+	assert.Equal("SELECT * FROM \"Sales\" GROUP BY CUBE (\"Year\", \"Country\")", query)
+	query, _ = Build(NewQuery("Sales").GroupBy("Region").GroupingSets([]interface{}{"Year", "Country"}, []interface{}{"Year"}, []interface{}{}).SetDialect(DialectPostgreSQL).Select())
+	assert.Equal("SELECT * FROM \"Sales\" GROUP BY \"Region\", GROUPING SETS ((\"Year\", \"Country\"), (\"Year\"), ())", query)
+
+	_, _, err := TryBuild(NewQuery("Sales").GroupBy("Year").WithCube().Select())
+	assert.EqualError(err, "rushia: CUBE is not supported by MySQL")
+	_, _, err = TryBuild(NewQuery("Sales").GroupingSets([]interface{}{"Year"}).Select())
+	assert.EqualError(err, "rushia: GROUPING SETS is not supported by MySQL")
+
+	_, _, err = TryBuild(NewQuery("Sales").GroupBy("a").WithRollup().GroupingSets([]interface{}{"b"}).SetDialect(DialectPostgreSQL).Select())
+	assert.EqualError(err, "rushia: WithRollup and WithCube cannot be combined with GroupingSets")
+	_, _, err = TryBuild(NewQuery("Sales").GroupBy("a").WithCube().GroupingSets([]interface{}{"b"}).SetDialect(DialectPostgreSQL).Select())
+	assert.EqualError(err, "rushia: WithRollup and WithCube cannot be combined with GroupingSets")
+}
+
+func TestAggregate(t *testing.T) {
+	assert := assert.New(t)
+	q := NewQuery("Orders").
+		Where("Status = ?", "paid").
+		GroupBy("UserID").
+		Having("? > ?", Count(), 10).
+		OrderByDesc(Sum("Orders.Total")).
+		Select("UserID", Count().As("Orders"), CountDistinct("ProductID").As("Products"), Avg("Total"), Min("Total"), Max("Total").As("Max"))
+	query, params := Build(q)
+	assert.Equal("SELECT `UserID`, COUNT(*) AS `Orders`, COUNT(DISTINCT `ProductID`) AS `Products`, AVG(`Total`), MIN(`Total`), MAX(`Total`) AS `Max` FROM `Orders` WHERE Status = ? GROUP BY `UserID` HAVING COUNT(*) > ? ORDER BY SUM(`Orders`.`Total`) DESC", query)
+	assertParamOrders(assert, []interface{}{"paid", 10}, params)
+
+	// The alias creates a new aggregate.
+	count := Count("ID")
+	_ = count.As("Total")
+	query, _ = Build(NewQuery("Users").Select(count))
+	assert.Equal("SELECT COUNT(`ID`) FROM `Users`", query)
+}
+
+func TestAggregateGroupConcat(t *testing.T) {
+	assert := assert.New(t)
+	q := NewQuery("Users").GroupBy("Type").Select("Type", GroupConcat("Name").Distinct().OrderBy("Name", OrderDesc).Separator("', ").As("Names"))
+	query, _ := Build(q)
+	assert.Equal("SELECT `Type`, GROUP_CONCAT(DISTINCT `Name` ORDER BY `Name` DESC SEPARATOR '\\', ') AS `Names` FROM `Users` GROUP BY `Type`", query)
+	query, _ = Build(q.Copy().SetDialect(DialectPostgreSQL))
+	assert.Equal("SELECT \"Type\", STRING_AGG(DISTINCT \"Name\", ''', ' ORDER BY \"Name\" DESC) AS \"Names\" FROM \"Users\" GROUP BY \"Type\"", query)
+	query, _ = Build(NewQuery("Users").Select(GroupConcat("Name")))
+	assert.Equal("SELECT GROUP_CONCAT(`Name` SEPARATOR ',') FROM `Users`", query)
+}
+
 //=======================================================
 // Join
 //=======================================================
@@ -1040,6 +1366,889 @@ func TestCopy(t *testing.T) {
 	assertParams(assert, []interface{}{30, "yamiodymel", "hello"}, params)
 }
 
+func TestCopyJoinsAndDuplicate(t *testing.T) {
+	assert := assert.New(t)
+	a := NewQuery("Users").LeftJoin("Orders", "Orders.UserID = Users.ID").Insert(H{"Name": "A"}).OnDuplicate(H{"Name": "A"})
+	b := a.Copy()
+	b.JoinWhere("Orders.Status = ?", "paid").OnDuplicate(H{"Count": 1})
+	assert.Len(a.Joins()[0].Conditions, 1)
+	assert.Len(a.duplicate, 1)
+}
+
+func TestClone(t *testing.T) {
+	assert := assert.New(t)
+	sub := NewQuery("Orders").Where("Status = ?", "paid").Select("UserID")
+	expr := NewExpr("FIND_IN_SET(?, Tags)", "vip")
+	a := NewQuery("Users").
+		LeftJoin("Profiles", "Profiles.UserID = Users.ID").
+		Where("ID IN ?", sub).
+		Where("Type IN ?", []string{"a", "b"}).
+		Where("?", expr).
+		Union(NewQuery("Admins").Select()).
+		Select("ID", Case().When("Age > 18", "adult").Else("child").As("Stage"))
+	before, beforeParams := Build(a)
+
+	b := a.Clone()
+	b.JoinWhere("Profiles.Public = ?", true).Where("Banned = ?", false).OrderByDesc("ID")
+	b.wheres[0].args[0].(*Query).Where("Total > ?", 100)
+	b.wheres[1].args[0].([]string)[0] = "x"
+	b.wheres[2].args[0].(*Expr).params[0] = "member"
+	b.unions[0].query.Where("Level > ?", 1)
+	b.selects[1].(*Expression).whens[0].value = "grown"
+
+	query, params := Build(a)
+	assert.Equal(before, query)
+	assert.Equal(beforeParams, params)
+
+	query, _ = Build(b)
+	assert.NotEqual(before, query)
+
+	c := NewQuery("Users").Insert([]H{{"Name": "A"}}).OnDuplicate(H{"Name": NewExpr("VALUES(?)", "A")})
+	d := c.Clone()
+	d.data.([]H)[0]["Name"] = "B"
+	d.duplicate["Name"].(*Expr).params[0] = "B"
+	_, params = Build(c)
+	assert.Equal([]interface{}{"A", "A"}, params)
+}
+
+func TestClear(t *testing.T) {
+	assert := assert.New(t)
+	base := NewQuery("Users").
+		LeftJoin("Orders", "Orders.UserID = Users.ID").
+		InnerJoin("Profiles", "Profiles.UserID = Users.ID").
+		Where("Type = ?", "VIP").
+		GroupBy("Type").WithRollup().
+		Having("COUNT(*) > ?", 1).
+		OrderByDesc("ID").
+		Union(NewQuery("Admins").Select()).
+		SetQueryOption("SQL_NO_CACHE").
+		Select("ID", "Type")
+
+	query, params := Build(base.Copy().ClearWhere().ClearHaving().ClearOrder().ClearGroup().ClearSelect().ClearJoins().ClearUnions().ClearOptions())
+	assert.Equal("SELECT * FROM `Users`", query)
+	assert.Empty(params)
+
+	query, _ = Build(base.Copy().ClearHaving().ClearGroup().ClearUnions().ClearOptions().RemoveJoin("Orders").ReplaceSelect("Name"))
+	assert.Equal("SELECT `Name` FROM `Users` INNER JOIN `Profiles` ON (Profiles.UserID = Users.ID) WHERE Type = ? ORDER BY `ID` DESC", query)
+
+	query, _ = Build(NewQuery("Users").Where("ID = ?", 1).SetQueryOption("FOR UPDATE").ClearOptions().Select())
+	assert.Equal("SELECT * FROM `Users` WHERE ID = ?", query)
+
+	query, _ = Build(NewQuery("Users").Where("ID = ?", 1).SelectOptions(SelectNoCache).ForUpdate().ClearLock().Select())
+	assert.Equal("SELECT SQL_NO_CACHE * FROM `Users` WHERE ID = ?", query)
+
+	query, params = Build(NewQuery("Users").Where("ID = ?", 1).Update(H{"Name": "A"}).ResetType().Delete())
+	assert.Equal("DELETE FROM `Users` WHERE ID = ?", query)
+	assert.Equal([]interface{}{1}, params)
+}
+
+func TestMerge(t *testing.T) {
+	assert := assert.New(t)
+	active := NewQuery("").Where("Status = ?", "active").OrWhere("Status = ?", "trial")
+	withOrders := NewQuery("").LeftJoin("Orders", "Orders.UserID = Users.ID").Having("COUNT(Orders.ID) > ?", 0)
+
+	q := NewQuery("Users").Where("Type = ?", "VIP").OrWhere("Type = ?", "Admin").Merge(active).Merge(withOrders).Select()
+	query, params := Build(q)
+	assert.Equal("SELECT * FROM `Users` LEFT JOIN `Orders` ON (Orders.UserID = Users.ID) WHERE (Type = ? OR Type = ?) AND (Status = ? OR Status = ?) HAVING COUNT(Orders.ID) > ?", query)
+	assertParamOrders(assert, []interface{}{"VIP", "Admin", "active", "trial", 0}, params)
+
+	// The merged conditions are copied.
+	q.JoinWhere("Orders.Paid = ?", true)
+	assert.Len(withOrders.Joins()[0].Conditions, 1)
+}
+
+//=======================================================
+// Model
+//=======================================================
+
+type modelUser struct {
+	ID       int
+	Username string
+	Password string
+}
+
+type modelAccount struct {
+	TenantID int    `rushia:"tenant_id,pk"`
+	No       string `rushia:"account_no,pk"`
+	Balance  int
+}
+
+func (modelAccount) TableName() string {
+	return "Accounts"
+}
+
+func TestModelInsert(t *testing.T) {
+	assert := assert.New(t)
+	u := &modelUser{ID: 1, Username: "YamiOdymel", Password: "test"}
+	query, params := Build(NewModelQuery(u).InsertModel())
+	assertEqual(assert, "INSERT INTO `model_user` (`id`, `username`, `password`) VALUES (?, ?, ?)", query)
+	assertParams(assert, []interface{}{1, "YamiOdymel", "test"}, params)
+}
+
+func TestModelUpdate(t *testing.T) {
+	assert := assert.New(t)
+	u := &modelUser{ID: 1, Username: "YamiOdymel", Password: "test"}
+	query, params := Build(NewModelQuery(u).UpdateModel())
+	assertEqual(assert, "UPDATE `model_user` SET `username` = ?, `password` = ? WHERE `id` = ?", query)
+	assertParams(assert, []interface{}{"YamiOdymel", "test", 1}, params)
+
+	a := modelAccount{TenantID: 2, No: "A001", Balance: 100}
+	query, params = Build(NewModelQuery(a).UpdateModel())
+	assertEqual(assert, "UPDATE `Accounts` SET `balance` = ? WHERE `tenant_id` = ? AND `account_no` = ?", query)
+	assertParamOrders(assert, []interface{}{100, 2, "A001"}, params)
+}
+
+func TestModelDelete(t *testing.T) {
+	assert := assert.New(t)
+	a := modelAccount{TenantID: 2, No: "A001"}
+	query, params := Build(NewModelQuery(&a).DeleteModel())
+	assertEqual(assert, "DELETE FROM `Accounts` WHERE `tenant_id` = ? AND `account_no` = ?", query)
+	assertParamOrders(assert, []interface{}{2, "A001"}, params)
+}
+
+func TestModelFindByPK(t *testing.T) {
+	assert := assert.New(t)
+	u := modelUser{ID: 1}
+	query, params := Build(NewModelQuery(u).FindByPK())
+	assertEqual(assert, "SELECT * FROM `model_user` WHERE `id` = ? LIMIT 1", query)
+	assertParams(assert, []interface{}{1}, params)
+}
+
+func TestModelPanics(t *testing.T) {
+	assert := assert.New(t)
+	type tag struct {
+		Name string
+	}
+	assert.Panics(func() {
+		NewModelQuery(struct{ ID int }{})
+	})
+	assert.Panics(func() {
+		NewModelQuery(tag{Name: "Go"}).DeleteModel()
+	})
+	assert.Panics(func() {
+		NewQuery("Users").UpdateModel()
+	})
+}
+
+func TestModelUnexportedFields(t *testing.T) {
+	assert := assert.New(t)
+	type session struct {
+		id    int
+		Token string `rushia:"token,pk"`
+	}
+	query, params := Build(NewModelQuery(&session{id: 1, Token: "abc"}).FindByPK())
+	assert.Equal("SELECT * FROM `session` WHERE `token` = ? LIMIT 1", query)
+	assert.Equal([]interface{}{"abc"}, params)
+
+	type secret struct {
+		id int
+	}
+	assert.PanicsWithValue("rushia: no primary key was found in the model", func() {
+		NewModelQuery(&secret{id: 1}).DeleteModel()
+	})
+}
+
+//=======================================================
+// Timestamps
+//=======================================================
+
+func mockNow(t *testing.T) time.Time {
+	now := time.Date(2023, 5, 18, 12, 0, 0, 0, time.UTC)
+	NowFunc = func() time.Time {
+		return now
+	}
+	t.Cleanup(func() {
+		NowFunc = time.Now
+	})
+	return now
+}
+
+func TestTimestampsInsert(t *testing.T) {
+	assert := assert.New(t)
+	now := mockNow(t)
+	query, params := Build(NewQuery("Users").Timestamps().Insert(H{
+		"Username": "YamiOdymel",
+	}))
+	assertEqual(assert, "INSERT INTO `Users` (`Username`, `created_at`, `updated_at`) VALUES (?, ?, ?)", query)
+	assertParams(assert, []interface{}{"YamiOdymel", now, now}, params)
+
+	u := struct {
+		Username  string
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}{
+		Username: "YamiOdymel",
+	}
+	query, params = Build(NewQuery("Users").Timestamps().Insert(u))
+	assertEqual(assert, "INSERT INTO `Users` (`username`, `created_at`, `updated_at`) VALUES (?, ?, ?)", query)
+	assertParams(assert, []interface{}{"YamiOdymel", now, now}, params)
+
+	query, params = Build(NewQuery("Users").Timestamps("CreatedAt", "UpdatedAt").OnDuplicate(H{
+		"Username": NewExpr("VALUES(Username)"),
+	}).Insert(H{
+		"Username": "YamiOdymel",
+	}))
+	assertEqual(assert, "INSERT INTO `Users` (`Username`, `CreatedAt`, `UpdatedAt`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `Username` = VALUES(Username), `UpdatedAt` = ?", query)
+	assertParams(assert, []interface{}{"YamiOdymel", now, now, now}, params)
+}
+
+func TestTimestampsUpdate(t *testing.T) {
+	assert := assert.New(t)
+	now := mockNow(t)
+	query, params := Build(NewQuery("Users").Timestamps().Where("ID = ?", 1).Update(H{
+		"Username": "YamiOdymel",
+	}))
+	assertEqual(assert, "UPDATE `Users` SET `Username` = ?, `updated_at` = ? WHERE ID = ?", query)
+	assertParams(assert, []interface{}{"YamiOdymel", now, 1}, params)
+
+	query, params = Build(NewQuery("Users").Timestamps().Where("ID = ?", 1).Patch(H{
+		"Username": "",
+		"Nickname": "Yami",
+	}))
+	assertEqual(assert, "UPDATE `Users` SET `Nickname` = ?, `updated_at` = ? WHERE ID = ?", query)
+	assertParams(assert, []interface{}{"Yami", now, 1}, params)
+}
+
+func TestSoftDelete(t *testing.T) {
+	assert := assert.New(t)
+	now := mockNow(t)
+	query, params := Build(NewQuery("Users").SoftDelete().Where("ID = ?", 1).Delete())
+	assertEqual(assert, "UPDATE `Users` SET `deleted_at` = ? WHERE ID = ? AND `Users`.`deleted_at` IS NULL", query)
+	assertParamOrders(assert, []interface{}{now, 1}, params)
+
+	query, params = Build(NewQuery("Users").SoftDelete().Timestamps().Where("ID = ?", 1).Delete())
+	assertEqual(assert, "UPDATE `Users` SET `deleted_at` = ?, `updated_at` = ? WHERE ID = ? AND `Users`.`deleted_at` IS NULL", query)
+	assertParams(assert, []interface{}{now, now, 1}, params)
+
+	query, params = Build(NewQuery("Users").SoftDelete().Unscoped().Where("ID = ?", 1).Delete())
+	assertEqual(assert, "DELETE FROM `Users` WHERE ID = ?", query)
+	assertParams(assert, []interface{}{1}, params)
+
+	// The DELETE options can't be used once the query was rewritten into an UPDATE query.
+	_, _, err := TryBuild(NewQuery("Users").SoftDelete().DeleteOptions(DeleteQuick).Where("ID = ?", 1).Delete())
+	assert.ErrorIs(err, ErrUnsupportedQueryOption)
+
+	query, _ = Build(NewQuery("Users").SoftDelete().Unscoped().DeleteOptions(DeleteQuick).Where("ID = ?", 1).Delete())
+	assert.Equal("DELETE QUICK FROM `Users` WHERE ID = ?", query)
+
+	// The raw `OR` condition is grouped so the soft deleted rows won't be deleted again.
+	query, params = Build(NewQuery("Users").SoftDelete().Where("a = ? OR b = ?", 1, 2).Delete())
+	assert.Equal("UPDATE `Users` SET `deleted_at` = ? WHERE (a = ? OR b = ?) AND `Users`.`deleted_at` IS NULL", query)
+	assert.Equal([]interface{}{now, 1, 2}, params)
+}
+
+func TestSoftDeleteSelect(t *testing.T) {
+	assert := assert.New(t)
+	q := NewQuery("Users").SoftDelete().Where("ID = ?", 1).OrWhere("ID = ?", 2).Select()
+	query, params := Build(q)
+	assertEqual(assert, "SELECT * FROM `Users` WHERE (ID = ? OR ID = ?) AND `Users`.`deleted_at` IS NULL", query)
+	assertParamOrders(assert, []interface{}{1, 2}, params)
+
+	query, _ = Build(q.Copy().WithTrashed())
+	assertEqual(assert, "SELECT * FROM `Users` WHERE ID = ? OR ID = ?", query)
+
+	query, _ = Build(q.Copy().OnlyTrashed())
+	assertEqual(assert, "SELECT * FROM `Users` WHERE (ID = ? OR ID = ?) AND `Users`.`deleted_at` IS NOT NULL", query)
+
+	query, _ = Build(NewQuery("Users").SoftDelete("RemovedAt").Exists())
+	assertEqual(assert, "SELECT EXISTS(SELECT * FROM `Users` WHERE `Users`.`RemovedAt` IS NULL)", query)
+
+	query, _ = Build(NewQuery("Users").SoftDelete().LeftJoin("Posts", "Posts.UserID = Users.ID").Select())
+	assert.Equal("SELECT * FROM `Users` LEFT JOIN `Posts` ON (Posts.UserID = Users.ID) WHERE `Users`.`deleted_at` IS NULL", query)
+
+	query, _ = Build(NewQuery(NewAlias("Users", "u")).SoftDelete().LeftJoin("Posts", "Posts.UserID = u.ID").Select())
+	assert.Equal("SELECT * FROM `Users` AS u LEFT JOIN `Posts` ON (Posts.UserID = u.ID) WHERE `u`.`deleted_at` IS NULL", query)
+
+	query, _ = Build(NewQuery("Users").SoftDelete().Where("a = ? OR b = ?", 1, 2).Select())
+	assert.Equal("SELECT * FROM `Users` WHERE (a = ? OR b = ?) AND `Users`.`deleted_at` IS NULL", query)
+
+	// The original query should not be modified by the build.
+	query, _ = Build(q)
+	assertEqual(assert, "SELECT * FROM `Users` WHERE (ID = ? OR ID = ?) AND `Users`.`deleted_at` IS NULL", query)
+}
+
+//=======================================================
+// Scope
+//=======================================================
+
+func TestScope(t *testing.T) {
+	assert := assert.New(t)
+	RegisterScope("Orders", func(q *Query) error {
+		q.Where("tenant_id = ?", 5)
+		return nil
+	})
+	t.Cleanup(ClearScopes)
+
+	query, params := Build(NewQuery("Orders").Where("ID = ?", 1).OrWhere("ID = ?", 2).Select())
+	assertEqual(assert, "SELECT * FROM `Orders` WHERE (ID = ? OR ID = ?) AND tenant_id = ?", query)
+	assertParamOrders(assert, []interface{}{1, 2, 5}, params)
+
+	query, params = Build(NewQuery("Users").Where("ID IN ?", NewQuery("Orders").Select("UserID")).Select())
+	assertEqual(assert, "SELECT * FROM `Users` WHERE ID IN (SELECT `UserID` FROM `Orders` WHERE tenant_id = ?)", query)
+	assertParams(assert, []interface{}{5}, params)
+
+	query, params = Build(NewQuery("Orders").Exists())
+	assertEqual(assert, "SELECT EXISTS(SELECT * FROM `Orders` WHERE tenant_id = ?)", query)
+	assertParams(assert, []interface{}{5}, params)
+
+	query, params = Build(NewQuery("Orders").Unscoped().Select())
+	assertEqual(assert, "SELECT * FROM `Orders`", query)
+	assertParams(assert, []interface{}{}, params)
+}
+
+func TestScopeTables(t *testing.T) {
+	assert := assert.New(t)
+	RegisterScope("Orders", func(q *Query) error {
+		q.Where("?? = ?", q.TableRef()+".tenant_id", 5)
+		return nil
+	})
+	t.Cleanup(ClearScopes)
+
+	query, params := Build(NewQuery(NewAlias("Orders", "o")).Select())
+	assert.Equal("SELECT * FROM `Orders` AS o WHERE `o`.`tenant_id` = ?", query)
+	assert.Equal([]interface{}{5}, params)
+
+	query, _ = Build(NewQuery(Ident("Orders")).Select())
+	assert.Equal("SELECT * FROM `Orders` WHERE `Orders`.`tenant_id` = ?", query)
+
+	query, _ = Build(NewQuery(Col("Orders").As("o")).Select())
+	assert.Equal("SELECT * FROM `Orders` AS `o` WHERE `o`.`tenant_id` = ?", query)
+
+	query, _ = Build(NewQuery("shop.Orders").Select())
+	assert.Equal("SELECT * FROM shop.Orders WHERE `shop`.`Orders`.`tenant_id` = ?", query)
+
+	query, params = Build(NewQuery("Users").LeftJoin("Orders", "Orders.UserID = Users.ID").OrJoinWhere("Orders.Gift = ?", true).Where("Users.ID = ?", 1).Select())
+	assert.Equal("SELECT * FROM `Users` LEFT JOIN `Orders` ON ((Orders.UserID = Users.ID OR Orders.Gift = ?) AND (`Orders`.`tenant_id` = ?)) WHERE Users.ID = ?", query)
+	assert.Equal([]interface{}{true, 5, 1}, params)
+
+	query, _ = Build(NewQuery("Users").InnerJoin(NewAlias("Orders", "o"), "o.UserID = Users.ID").Select())
+	assert.Equal("SELECT * FROM `Users` INNER JOIN `Orders` AS o ON ((o.UserID = Users.ID) AND (`o`.`tenant_id` = ?))", query)
+
+	// The raw conditions are always grouped so their `OR` can't bypass the scopes.
+	query, params = Build(NewQuery("Orders").Where("status = ? OR status = ?", 1, 2).Select())
+	assert.Equal("SELECT * FROM `Orders` WHERE (status = ? OR status = ?) AND `Orders`.`tenant_id` = ?", query)
+	assert.Equal([]interface{}{1, 2, 5}, params)
+
+	query, params = Build(NewQuery("Users").LeftJoin("Orders", "Orders.UserID = Users.ID OR 1 = 1").Select())
+	assert.Equal("SELECT * FROM `Users` LEFT JOIN `Orders` ON ((Orders.UserID = Users.ID OR 1 = 1) AND (`Orders`.`tenant_id` = ?))", query)
+	assert.Equal([]interface{}{5}, params)
+
+	query, _ = Build(NewQuery("Users").LeftJoin("Orders", "Orders.UserID = Users.ID").Unscoped().Select())
+	assert.Equal("SELECT * FROM `Users` LEFT JOIN `Orders` ON (Orders.UserID = Users.ID)", query)
+}
+
+func TestGlobalScope(t *testing.T) {
+	assert := assert.New(t)
+	RegisterGlobalScope(func(q *Query) error {
+		if q.Type() == QueryTypeDelete {
+			return errors.New("deletion is not allowed")
+		}
+		if q.Type() == QueryTypeSelect {
+			q.SetTable("Archived" + q.table.(string))
+		}
+		return nil
+	})
+	t.Cleanup(ClearScopes)
+
+	query, _, err := TryBuild(NewQuery("Users").Select())
+	assert.NoError(err)
+	assertEqual(assert, "SELECT * FROM `ArchivedUsers`", query)
+
+	_, _, err = TryBuild(NewQuery("Users").Where("ID = ?", 1).Delete())
+	assert.EqualError(err, "deletion is not allowed")
+	assert.Panics(func() {
+		Build(NewQuery("Users").Where("ID = ?", 1).Delete())
+	})
+}
+
+func TestQueryScope(t *testing.T) {
+	assert := assert.New(t)
+	active := func(q *Query) error {
+		q.Where("Status = ?", "active")
+		return nil
+	}
+	q := NewQuery("Users").Scope(active).Select()
+	query, params := Build(q)
+	assertEqual(assert, "SELECT * FROM `Users` WHERE Status = ?", query)
+	assertParams(assert, []interface{}{"active"}, params)
+
+	query, params = Build(q.Unscoped())
+	assertEqual(assert, "SELECT * FROM `Users` WHERE Status = ?", query)
+	assertParams(assert, []interface{}{"active"}, params)
+}
+
+func TestTryBuild(t *testing.T) {
+	assert := assert.New(t)
+	_, _, err := TryBuild(NewQuery("Users").Where("ID = ", 1).Select())
+	assert.EqualError(err, "rushia: incorrect where condition usage")
+}
+
+func TestWhenUnlessApply(t *testing.T) {
+	assert := assert.New(t)
+	name, age := "", 18
+	active := func(q *Query) *Query {
+		return q.Where("Status = ?", "active")
+	}
+	query, params := Build(NewQuery("Users").
+		When(name != "", func(q *Query) { q.Where("Name = ?", name) }).
+		When(age != 0, func(q *Query) { q.Where("Age >= ?", age) }).
+		When(false, func(q *Query) { q.OrderByAsc("ID") }, func(q *Query) { q.OrderByDesc("ID") }).
+		Unless(name != "", func(q *Query) { q.Limit(10) }).
+		Apply(active, nilApply).
+		Select())
+	assert.Equal("SELECT * FROM `Users` WHERE Age >= ? AND Status = ? ORDER BY `ID` DESC LIMIT 10", query)
+	assertParamOrders(assert, []interface{}{18, "active"}, params)
+}
+
+func nilApply(q *Query) *Query {
+	return nil
+}
+
+//=======================================================
+// Inspect
+//=======================================================
+
+func TestInspect(t *testing.T) {
+	assert := assert.New(t)
+	q := NewQuery("Users").
+		As("u").
+		LeftJoin("Posts", "Posts.UserID = Users.ID").
+		JoinWhere("Posts.Status = ?", "published").
+		Where("?? = ?", "ID", 1).
+		OrWhere("Age > ?", 18).
+		Having("Total > ?", 5).
+		GroupBy("ID").
+		Limit(10, 20).
+		Select("ID", "Username")
+
+	assert.Equal(QueryTypeSelect, q.Type())
+	assert.Equal("SELECT", q.Type().String())
+	assert.Equal("Users", q.Table())
+	assert.Equal("u", q.Alias())
+	assert.Equal([]Condition{
+		{Query: "`ID` = ?", Args: []interface{}{1}},
+		{Query: "Age > ?", Args: []interface{}{18}, Or: true},
+	}, q.Conditions())
+	assert.Equal([]Condition{{Query: "Total > ?", Args: []interface{}{5}}}, q.HavingConditions())
+	assert.Equal([]interface{}{"ID", "Username"}, q.SelectedColumns())
+	assert.Equal([]Join{{
+		Type:  "LEFT JOIN",
+		Table: "Posts",
+		Conditions: []Condition{
+			{Query: "Posts.UserID = Users.ID", Args: []interface{}{}},
+			{Query: "Posts.Status = ?", Args: []interface{}{"published"}},
+		},
+	}}, q.Joins())
+	assert.Equal([]interface{}{"ID"}, q.Groups())
+	from, count := q.LimitValues()
+	assert.Equal(10, from)
+	assert.Equal(20, count)
+
+	// The inspected values should not modify the query.
+	q.Conditions()[0].Args[0] = 2
+	assert.Equal(1, q.Conditions()[0].Args[0])
+}
+
+func TestWalk(t *testing.T) {
+	assert := assert.New(t)
+	orders := NewQuery("Orders").Select("UserID")
+	payments := NewQuery("Payments").Select("UserID")
+	tags := NewQuery("Tags").Select("Name")
+	q := NewQuery("Users").
+		InnerJoin(NewQuery("Posts").Select().As("p"), "p.UserID = Users.ID").
+		Where("ID IN ?", orders).
+		Where("EXISTS ?", NewRawQuery("SELECT 1 FROM Bans WHERE UserID IN (?)", payments)).
+		Select("ID", NewExpr("(?) AS Tag", tags))
+
+	var tables []interface{}
+	Walk(q, func(q *Query) bool {
+		tables = append(tables, q.Table())
+		return true
+	})
+	assert.Equal([]interface{}{"Users", "Tags", "Posts", "Orders", nil, "Payments"}, tables)
+
+	tables = nil
+	Walk(q, func(q *Query) bool {
+		tables = append(tables, q.Table())
+		return q.Type() != QueryTypeRawQuery
+	})
+	assert.Equal([]interface{}{"Users", "Tags", "Posts", "Orders", nil}, tables)
+}
+
+//=======================================================
+// Identifier
+//=======================================================
+
+func TestIdent(t *testing.T) {
+	assert := assert.New(t)
+	query, params := Build(NewQuery(Ident("shop.Users")).
+		LeftJoin(Col("Orders").As("o"), "o.UserID = Users.ID").
+		Where("?? = ?", Ident("Users.ID"), 1).
+		Where("?? = ?", "Nick`name", "Yami").
+		GroupByColumns(Ident("Users.ID")).
+		OrderByColumns(Raw("COUNT(*) DESC"), Ident("Users.ID")).
+		Select(Ident("Users.*"), Col("o.Total").As("Total"), Raw("COUNT(*) AS Count"), Ident("count")))
+	assertEqual(assert, "SELECT `Users`.*, `o`.`Total` AS `Total`, COUNT(*) AS Count, `count` FROM `shop`.`Users` LEFT JOIN `Orders` AS `o` ON (o.UserID = Users.ID) WHERE `Users`.`ID` = ? AND `Nick``name` = ? GROUP BY `Users`.`ID` ORDER BY COUNT(*) DESC, `Users`.`ID`", query)
+	assertParamOrders(assert, []interface{}{1, "Yami"}, params)
+
+	query, params = Build(NewQuery("Users").Where("ID = ?", 1).Update(H{"UpdatedAt": Raw("NOW()"), "Nickname": Ident("Username")}))
+	assertEqual(assert, "UPDATE `Users` SET `UpdatedAt` = NOW(), `Nickname` = `Username` WHERE ID = ?", query)
+	assertParams(assert, []interface{}{1}, params)
+
+	query, _ = Build(NewQuery(NewAlias("shop.Users", "u")).Select())
+	assertEqual(assert, "SELECT * FROM `shop`.`Users` AS u", query)
+
+	// The string slices could still be passed to `OrderBy` and `GroupBy`.
+	columns := []string{"Type", "Name"}
+	query, _ = Build(NewQuery("Users").GroupBy(columns...).OrderBy(columns...).Select())
+	assert.Equal("SELECT * FROM `Users` GROUP BY `Type`, `Name` ORDER BY Type, Name", query)
+}
+
+func TestDialectIdentifiers(t *testing.T) {
+	assert := assert.New(t)
+	query, params := Build(NewQuery("Users").Where("`Name` = ? AND Note <> '`x`'", "Yami").Comment("`y`").SetDialect(DialectPostgreSQL).Select("ID", Ident("a\"b`c")))
+	assert.Equal(`SELECT "ID", "a""b`+"`"+`c" FROM "Users" WHERE "Name" = $1 AND Note <> '`+"`x`"+`' /* `+"`y`"+` */`, query)
+	assert.Equal([]interface{}{"Yami"}, params)
+
+	query, _ = Build(NewQuery("Users").Where("ID IN ?", NewQuery("Orders").Select("UserID")).SetDialect(DialectPostgreSQL).Select())
+	assert.Equal(`SELECT * FROM "Users" WHERE ID IN (SELECT "UserID" FROM "Orders")`, query)
+}
+
+func TestDialectPlaceholders(t *testing.T) {
+	assert := assert.New(t)
+	sub := NewQuery("Orders").Where("Total > ?", 100).Limit(5, 10).Select("UserID")
+	query, params := Build(NewQuery("Users").Where("Name = ? AND Note <> '?'", "Yami").Where("ID IN ?", sub).Where("Tags ?| array['a'] AND Age > ?", 18).Limit(20, 10).SetDialect(DialectPostgreSQL).Select())
+	assert.Equal(`SELECT * FROM "Users" WHERE Name = $1 AND Note <> '?' AND ID IN (SELECT "UserID" FROM "Orders" WHERE Total > $2 LIMIT 10 OFFSET 5) AND Tags ?| array['a'] AND Age > $3 LIMIT 10 OFFSET 20`, query)
+	assert.Equal([]interface{}{"Yami", 100, 18}, params)
+
+	query, params = Build(NewQuery("Users").Where("ID = ? OR Name = ?", 1, "Yami").SetDialect(DialectPostgreSQL).Exists())
+	assert.Equal(`SELECT EXISTS(SELECT * FROM "Users" WHERE ID = $1 OR Name = $2)`, query)
+	assert.Equal([]interface{}{1, "Yami"}, params)
+
+	assert.Equal(`SELECT * FROM "Users" WHERE Name = 'Yami' LIMIT 10 OFFSET 20`, NewQuery("Users").Where("Name = ?", "Yami").Limit(20, 10).SetDialect(DialectPostgreSQL).Select().ToSQL())
+
+	_, _, err := TryBuild(NewQuery("Users").OrderByField("Type", "a", "b").SetDialect(DialectPostgreSQL).Select())
+	assert.EqualError(err, "rushia: OrderByField is not supported by PostgreSQL, use OrderByExpr instead")
+}
+
+func TestStrictIdentifiers(t *testing.T) {
+	assert := assert.New(t)
+	StrictIdentifiers = true
+	t.Cleanup(func() {
+		StrictIdentifiers = false
+	})
+	query, _ := Build(NewQuery("Users").
+		GroupBy("Users.Type").
+		OrderByColumns("Name DESC", Raw("ID ASC")).
+		Select("Users.Name", "COUNT(*)", Raw("COUNT(*)")))
+	assertEqual(assert, "SELECT `Users`.`Name`, `COUNT(*)`, COUNT(*) FROM `Users` GROUP BY `Users`.`Type` ORDER BY `Name DESC`, ID ASC", query)
+
+	query, _ = Build(NewQuery("Users").Insert(H{"Users.Name": "Yami"}))
+	assertEqual(assert, "INSERT INTO `Users` (`Users`.`Name`) VALUES (?)", query)
+}
+
+//=======================================================
+// Sortable & Filterable
+//=======================================================
+
+func TestSortable(t *testing.T) {
+	assert := assert.New(t)
+	sortable := Sortable{
+		"created": "users.created_at",
+		"name":    "name",
+	}
+	q, err := sortable.Apply(NewQuery("Users"), "-created, name,+name")
+	assert.NoError(err)
+	query, _ := Build(q.Select())
+	assertEqual(assert, "SELECT * FROM `Users` ORDER BY `users`.`created_at` DESC, `name` ASC, `name` ASC", query)
+
+	_, err = sortable.Apply(NewQuery("Users"), "-created,password")
+	assert.ErrorIs(err, ErrUnknownField)
+	assert.EqualError(err, "rushia: unknown field: password")
+
+	_, err = sortable.Apply(NewQuery("Users"), "name; DROP TABLE Users")
+	assert.ErrorIs(err, ErrUnknownField)
+}
+
+func TestFilterable(t *testing.T) {
+	assert := assert.New(t)
+	filterable := Filterable{
+		"name":   {Column: "users.name", Operators: []FilterOperator{FilterEq, FilterLike}},
+		"age":    {Column: "users.age"},
+		"status": {Column: "status", Operators: []FilterOperator{FilterIn}},
+	}
+	q, err := filterable.Apply(NewQuery("Users"), map[string][]string{
+		"name[like]": {"50%_off"},
+		"age[gte]":   {"18"},
+		"age[lt]":    {"30"},
+		"status[in]": {"active,banned", "pending"},
+	})
+	assert.NoError(err)
+	query, params := Build(q.Select())
+	assertEqual(assert, "SELECT * FROM `Users` WHERE `users`.`age` >= ? AND `users`.`age` < ? AND `users`.`name` LIKE ? AND `status` IN (?, ?, ?)", query)
+	assertParamOrders(assert, []interface{}{"18", "30", `%50\%\_off%`, "active", "banned", "pending"}, params)
+
+	_, err = filterable.Apply(NewQuery("Users"), map[string][]string{"password": {"1234"}})
+	assert.ErrorIs(err, ErrUnknownField)
+	_, err = filterable.Apply(NewQuery("Users"), map[string][]string{"status": {"active"}})
+	assert.ErrorIs(err, ErrUnsupportedOperator)
+	_, err = filterable.Apply(NewQuery("Users"), map[string][]string{"age[between]": {"1"}})
+	assert.ErrorIs(err, ErrUnsupportedOperator)
+	assert.EqualError(err, "rushia: unsupported filter operator: age[between]")
+
+	// The query should be untouched if any of the filters was not allowed.
+	q = NewQuery("Users")
+	_, err = filterable.Apply(q, map[string][]string{"age": {"18"}, "name": {"Yami"}, "status": {"active"}})
+	assert.ErrorIs(err, ErrUnsupportedOperator)
+	query, params = Build(q.Select())
+	assert.Equal("SELECT * FROM `Users`", query)
+	assert.Empty(params)
+}
+
+func TestWhereStruct(t *testing.T) {
+	assert := assert.New(t)
+	type userFilter struct {
+		Name     string    `rushia:"name,op=like"`
+		MinAge   int       `rushia:"age,op=gte"`
+		MaxAge   *int      `rushia:"age,op=lte"`
+		Statuses []string  `rushia:"status,op=in"`
+		Since    time.Time `rushia:"created_at,op=gte"`
+		Type     string
+		Ignored  string `rushia:"-"`
+	}
+	maxAge := 0
+	query, params := Build(NewQuery("Users").WhereStruct(userFilter{
+		Name:     "50%",
+		MaxAge:   &maxAge,
+		Statuses: []string{"active", "trial"},
+		Type:     "vip",
+		Ignored:  "x",
+	}).Select())
+	assert.Equal("SELECT * FROM `Users` WHERE `name` LIKE ? AND `age` <= ? AND `status` IN (?, ?) AND `type` = ?", query)
+	assertParamOrders(assert, []interface{}{"%50\\%%", 0, "active", "trial", "vip"}, params)
+
+	_, _, err := TryBuild(NewQuery("Users").WhereStruct(struct {
+		Name string `rushia:"name,op=regexp"`
+	}{Name: "a"}).Select())
+	assert.ErrorIs(err, ErrUnsupportedOperator)
+
+	_, _, err = TryBuild(NewQuery("Users").WhereStruct("name").Select())
+	assert.EqualError(err, "rushia: WhereStruct requires a struct, got string")
+}
+
+//=======================================================
+// Lexer
+//=======================================================
+
+func TestLexerLiterals(t *testing.T) {
+	assert := assert.New(t)
+	query, params := Build(NewQuery("Users").Where("Note = 'Why?' AND Title = \"?\" AND `Is?` = ? AND Name = 'It''s ?' AND Bio = 'a\\'?' AND ID = ?", true, 1).Select())
+	assertEqual(assert, "SELECT * FROM `Users` WHERE Note = 'Why?' AND Title = \"?\" AND `Is?` = ? AND Name = 'It''s ?' AND Bio = 'a\\'?' AND ID = ?", query)
+	assertParamOrders(assert, []interface{}{true, 1}, params)
+
+	query, params = Build(NewQuery("Users").Where("ID = ? /* why? */ AND Age > ? -- older?\n", 1, 18).Select())
+	assert.Equal("SELECT * FROM `Users` WHERE ID = ? /* why? */ AND Age > ? -- older?", query)
+	assertParamOrders(assert, []interface{}{1, 18}, params)
+
+	query, params = Build(NewQuery("Users").Where("Tags ?| array['a', 'b'] AND Tags ?& ? AND ID = ?", "c", 1).Select())
+	assert.Equal("SELECT * FROM `Users` WHERE Tags ?| array['a', 'b'] AND Tags ?& ? AND ID = ?", query)
+	assertParamOrders(assert, []interface{}{"c", 1}, params)
+
+	// The `#>`, `#>>` JSON operators and the `#` XOR operator in PostgreSQL are not the comments.
+	query, params = Build(NewQuery("Users").Where("data #>> '{a}' = ? AND data #> ? IS NOT NULL", "x", "{b}").SetDialect(DialectPostgreSQL).Select())
+	assert.Equal(`SELECT * FROM "Users" WHERE data #>> '{a}' = $1 AND data #> $2 IS NOT NULL`, query)
+	assert.Equal([]interface{}{"x", "{b}"}, params)
+
+	query, params = Build(NewQuery("Users").SetDialect(DialectPostgreSQL).Where("Flags # ? = ?", 1, 0).Select())
+	assert.Equal(`SELECT * FROM "Users" WHERE Flags # $1 = $2`, query)
+	assert.Equal([]interface{}{1, 0}, params)
+
+	query, params = Build(NewQuery("Users").Where("ID = ? # why?\n", 1).Select())
+	assert.Equal("SELECT * FROM `Users` WHERE ID = ? # why?", query)
+	assert.Equal([]interface{}{1}, params)
+}
+
+func TestLexerSubQueryShifting(t *testing.T) {
+	assert := assert.New(t)
+	subQuery := NewQuery("Orders").Where("Total > ? AND Status = ?", 100, "paid").Select("UserID")
+	query, params := Build(NewQuery("Users").Where("ID IN ? AND Age > ? AND Type IN ?", subQuery, 18, []string{"a", "b"}).Select())
+	assertEqual(assert, "SELECT * FROM `Users` WHERE ID IN (SELECT `UserID` FROM `Orders` WHERE Total > ? AND Status = ?) AND Age > ? AND Type IN (?, ?)", query)
+	assertParamOrders(assert, []interface{}{100, "paid", 18, "a", "b"}, params)
+
+	expr := NewExpr("IF((?) > 0, ?, ?)", NewQuery("Orders").Where("UserID = ?", 1).Select("COUNT(*)"), "yes", "no")
+	query, params = Build(NewQuery("Users").Select(expr))
+	assertEqual(assert, "SELECT IF((SELECT COUNT(*) FROM `Orders` WHERE UserID = ?) > 0, ?, ?) FROM `Users`", query)
+	assertParamOrders(assert, []interface{}{1, "yes", "no"}, params)
+
+	// Building twice should not modify the expression.
+	query, _ = Build(NewQuery("Users").Select(expr))
+	assertEqual(assert, "SELECT IF((SELECT COUNT(*) FROM `Orders` WHERE UserID = ?) > 0, ?, ?) FROM `Users`", query)
+}
+
+func TestLexerEscape(t *testing.T) {
+	assert := assert.New(t)
+	query, params := Build(NewRawQuery("SELECT ?? FROM Users WHERE ?? = ? AND Note = '??'", "Username", "ID", 1))
+	assert.Equal("SELECT `Username` FROM Users WHERE `ID` = ? AND Note = '??'", query)
+	assertParamOrders(assert, []interface{}{1}, params)
+
+	query, params = Build(NewQuery("Users").Where("?? = ? AND Note = '??' AND ?? > ?", "ID", 1, "Age", 18).Select())
+	assertEqual(assert, "SELECT * FROM `Users` WHERE `ID` = ? AND Note = '??' AND `Age` > ?", query)
+	assertParamOrders(assert, []interface{}{1, 18}, params)
+
+	_, _, err := TryBuild(NewQuery("Users").Where("?? = ?", 1, 1).Select())
+	assert.Error(err)
+}
+
+//=======================================================
+// Named
+//=======================================================
+
+func TestNamed(t *testing.T) {
+	assert := assert.New(t)
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	query, params := Build(NewQuery("Orders").Where("CreatedAt BETWEEN :from AND :to", Named{"from": from, "to": to}).Select())
+	assertEqual(assert, "SELECT * FROM `Orders` WHERE CreatedAt BETWEEN ? AND ?", query)
+	assertParamOrders(assert, []interface{}{from, to}, params)
+
+	query, params = Build(NewQuery("Orders").Where("UserID IN :ids OR SellerID IN :ids", Named{"ids": []int{1, 2}}).Having("Total > :min", Named{"min": 100}).Select())
+	assertEqual(assert, "SELECT * FROM `Orders` WHERE UserID IN (?, ?) OR SellerID IN (?, ?) HAVING Total > ?", query)
+	assertParamOrders(assert, []interface{}{1, 2, 1, 2, 100}, params)
+
+	query, params = Build(NewQuery("Users").LeftJoin("Orders", "Orders.UserID = Users.ID AND Orders.Status = :status", Named{"status": "paid"}).Select())
+	assertEqual(assert, "SELECT * FROM `Users` LEFT JOIN `Orders` ON (Orders.UserID = Users.ID AND Orders.Status = ?)", query)
+	assertParams(assert, []interface{}{"paid"}, params)
+}
+
+func TestNamedRawQuery(t *testing.T) {
+	assert := assert.New(t)
+	query, params := Build(NewRawQuery("SELECT * FROM Users WHERE ID = :id OR ParentID = :id AND Note <> ':id' AND CreatedAt::date = :date", Named{"id": 1, "date": "2023-01-01"}))
+	assert.Equal("SELECT * FROM Users WHERE ID = ? OR ParentID = ? AND Note <> ':id' AND CreatedAt::date = ?", query)
+	assertParamOrders(assert, []interface{}{1, 1, "2023-01-01"}, params)
+
+	query, params = Build(NewQuery("Users").Update(H{"Score": NewExpr(":score + :score", Named{"score": 5})}).Where("ID = ?", 1))
+	assertEqual(assert, "UPDATE `Users` SET `Score` = ? + ? WHERE ID = ?", query)
+	assertParamOrders(assert, []interface{}{5, 5, 1}, params)
+}
+
+func TestNamedErrors(t *testing.T) {
+	assert := assert.New(t)
+	_, _, err := TryBuild(NewQuery("Users").Where("ID = :id", Named{"uid": 1}).Select())
+	assert.ErrorIs(err, ErrMissingNamedParam)
+	_, _, err = TryBuild(NewQuery("Users").Where("ID = :id", Named{"id": 1, "name": "Yami"}).Select())
+	assert.ErrorIs(err, ErrUnusedNamedParam)
+	assert.EqualError(err, "rushia: unused named parameter: name")
+	_, _, err = TryBuild(NewRawQuery("SELECT * FROM Users WHERE ID = :id AND Age > ?", Named{"id": 1}, 18))
+	assert.ErrorIs(err, ErrMixedNamedParam)
+	_, _, err = TryBuild(NewQuery("Users").Insert(H{"Score": NewExpr(":score", Named{})}))
+	assert.ErrorIs(err, ErrMissingNamedParam)
+}
+
+//=======================================================
+// Interpolate
+//=======================================================
+
+func TestInterpolate(t *testing.T) {
+	assert := assert.New(t)
+	createdAt := time.Date(2023, 5, 18, 12, 30, 0, 0, time.UTC)
+	q := NewQuery("Users").
+		Where("Username = ?", "Yami'Odymel\\").
+		Where("CreatedAt > ?", createdAt).
+		Where("Deleted = ?", false).
+		Where("Nickname = ? AND Note <> '?'", nil).
+		Where("Score IN ?", []float64{1.5, 2}).
+		Where("ID IN ?", NewQuery("Orders").Where("Total > ?", 100).Select("UserID")).
+		Select()
+	assert.Equal("SELECT * FROM `Users` WHERE Username = 'Yami\\'Odymel\\\\' AND CreatedAt > '2023-05-18 12:30:00' AND Deleted = FALSE AND Nickname = NULL AND Note <> '?' AND Score IN (1.5, 2) AND ID IN (SELECT `UserID` FROM `Orders` WHERE Total > 100)", q.ToSQL())
+	assert.Equal("SELECT * FROM \"Users\" WHERE Username = 'Yami''Odymel\\' AND CreatedAt > '2023-05-18 12:30:00+00:00' AND Deleted = FALSE AND Nickname = NULL AND Note <> '?' AND Score IN (1.5, 2) AND ID IN (SELECT \"UserID\" FROM \"Orders\" WHERE Total > 100)", Interpolate(q, DialectPostgreSQL))
+}
+
+func TestInterpolateRedact(t *testing.T) {
+	assert := assert.New(t)
+	q := NewQuery("Users").Insert(H{
+		"Username": "YamiOdymel",
+		"Password": "secret",
+	})
+	assert.Contains(q.ToSQL("password"), "'[REDACTED]'")
+	assert.Contains(q.ToSQL("password"), "'YamiOdymel'")
+	assert.NotContains(q.ToSQL("password"), "secret")
+	assert.Equal("INSERT INTO `Users` (`Hash`) VALUES (X'dead')", NewQuery("Users").Insert(H{"Hash": []byte{0xDE, 0xAD}}).ToSQL())
+	assert.Equal("INSERT INTO \"Users\" (\"Hash\") VALUES ('\\xdead')", Interpolate(NewQuery("Users").Insert(H{"Hash": []byte{0xDE, 0xAD}}), DialectPostgreSQL))
+
+	q = NewQuery("Users").
+		Where("Username = ?", "YamiOdymel").
+		Where("ID IN ?", NewQuery("Tokens").Where("`password` = SHA1(?)", "secret").Select("UserID")).
+		Select()
+	assert.Equal("SELECT * FROM `Users` WHERE Username = 'YamiOdymel' AND ID IN (SELECT `UserID` FROM `Tokens` WHERE `password` = SHA1('[REDACTED]'))", q.ToSQL("Password"))
+	query, params := Build(q)
+	assertEqual(assert, "SELECT * FROM `Users` WHERE Username = ? AND ID IN (SELECT `UserID` FROM `Tokens` WHERE `password` = SHA1(?))", query)
+	assertParamOrders(assert, []interface{}{"YamiOdymel", "secret"}, params)
+
+	// The redacted column should be kept by `Patch`, and the zero value is still eliminated.
+	query = NewQuery("Users").Where("ID = ?", 1).Patch(H{"name": "n", "password": "secret"}).ToSQL("password")
+	assert.Contains(query, "`password` = '[REDACTED]'")
+	assert.Contains(query, "`name` = 'n'")
+	assert.NotContains(query, "secret")
+	assert.Equal("UPDATE `Users` SET `name` = 'n' WHERE ID = 1", NewQuery("Users").Where("ID = ?", 1).Patch(H{"name": "n", "password": ""}).ToSQL("password"))
+
+	assert.Equal("UPDATE `Users` SET `password` = SHA2('[REDACTED]') WHERE ID = 1", NewQuery("Users").Where("ID = ?", 1).SetExpr("password", NewExpr("SHA2(?)", "secret")).ToSQL("password"))
+
+	assert.Equal("SELECT * FROM `Users` WHERE (`name`, `password`) IN (('n', '[REDACTED]'))", NewQuery("Users").WhereIn([]string{"name", "password"}, [][]interface{}{{"n", "secret"}}).Select().ToSQL("password"))
+	assert.Equal("SELECT * FROM `Users` WHERE (`name`, `password`) IN (('n', '[REDACTED]'))", NewQuery("Users").WhereIn([]string{"name", "password"}, []H{{"name": "n", "password": "secret"}}).Select().ToSQL("password"))
+}
+
+//=======================================================
+// Expression
+//=======================================================
+
+func TestExpressionSelect(t *testing.T) {
+	assert := assert.New(t)
+	level := Case().
+		When("Score >= 90", "A").
+		When(NewExpr("Score >= ?", 60), "B").
+		Else("C")
+	query, params := Build(NewQuery("Users").Select("ID", level.As("Level"), Func("DATE_FORMAT", Ident("CreatedAt"), "%Y-%m").As("Month"), Coalesce(Ident("Nickname"), Ident("Username"), nil)))
+	assert.Equal("SELECT `ID`, CASE WHEN Score >= 90 THEN ? WHEN Score >= ? THEN ? ELSE ? END AS `Level`, DATE_FORMAT(`CreatedAt`, ?) AS `Month`, COALESCE(`Nickname`, `Username`, NULL) FROM `Users`", query)
+	assertParamOrders(assert, []interface{}{"A", 60, "B", "C", "%Y-%m"}, params)
+}
+
+func TestExpressionUpdate(t *testing.T) {
+	assert := assert.New(t)
+	query, params := Build(NewQuery("Products").Where("ID = ?", 1).Update(H{
+		"Price": Cast(Mul(Ident("Price"), Sub(1, 0.2)), "DECIMAL(10,2)"),
+		"Label": Concat(Ident("Name"), " - ", Case(Ident("Status")).When(1, "On sale").Else("Sold out")),
+	}))
+	assertEqual(assert, "UPDATE `Products` SET `Price` = CAST((`Price` * (? - ?)) AS DECIMAL(10,2)), `Label` = CONCAT(`Name`, ?, CASE `Status` WHEN ? THEN ? ELSE ? END) WHERE ID = ?", query)
+	assert.Len(params, 7)
+	assert.Equal(1, params[len(params)-1])
+}
+
+func TestExpressionWhereAndOrderBy(t *testing.T) {
+	assert := assert.New(t)
+	subQuery := NewQuery("Orders").Where("Status = ?", "paid").Select(Max("Total"))
+	query, params := Build(NewQuery("Users").
+		Where("? > ?", Add(Ident("Balance"), Coalesce(subQuery, 0)), 100).
+		OrderByAsc(Case().When(NewExpr("Type = ?", "VIP"), 0).Else(1)).
+		Select())
+	assert.Equal("SELECT * FROM `Users` WHERE (`Balance` + COALESCE((SELECT MAX(`Total`) FROM `Orders` WHERE Status = ?), ?)) > ? ORDER BY CASE WHEN Type = ? THEN ? ELSE ? END ASC", query)
+	assertParamOrders(assert, []interface{}{"paid", 0, 100, "VIP", 0, 1}, params)
+
+	var tables []interface{}
+	Walk(NewQuery("Users").Where("? > ?", Add(Ident("Balance"), Coalesce(subQuery, 0)), 100).Select(), func(q *Query) bool {
+		tables = append(tables, q.Table())
+		return true
+	})
+	assert.Equal([]interface{}{"Users", "Orders"}, tables)
+
+	// The expressions are able to be nested in `NewExpr`.
+	query, params = Build(NewQuery("Users").Select(NewExpr("IF(? > ?, ?, ?)", Add(Ident("A"), 1), 2, Sum("B"), "x")))
+	assert.Equal("SELECT IF((`A` + ?) > ?, SUM(`B`), ?) FROM `Users`", query)
+	assertParamOrders(assert, []interface{}{1, 2, "x"}, params)
+
+	_, _, err := TryBuild(NewQuery("Users").Select(Case().Else(1)))
+	assert.EqualError(err, "rushia: the CASE expression requires at least one WHEN")
+}
+
 //=======================================================
 // Others
 //=======================================================
@@ -1062,7 +2271,7 @@ func TestComplexQueries(t *testing.T) {
 		Select("JobID", "AVG(Salary)")
 	query, params := Build(employees)
 
-	assertEqual(assert, "SELECT `JobID`, AVG(Salary) FROM `Employees` HAVING AVG(Salary) < (SELECT MAX(MyAVG) FROM (SELECT `JobID`, AVG(MinSalary) AS MyAVG FROM `Jobs` WHERE JobID IN (SELECT `JobID` FROM `JobHistories` WHERE DepartmentID BETWEEN ? AND ?) GROUP BY `JobID`) AS SS) GROUP BY `JobID`", query)
+	assertEqual(assert, "SELECT `JobID`, AVG(Salary) FROM `Employees` GROUP BY `JobID` HAVING AVG(Salary) < (SELECT MAX(MyAVG) FROM (SELECT `JobID`, AVG(MinSalary) AS MyAVG FROM `Jobs` WHERE JobID IN (SELECT `JobID` FROM `JobHistories` WHERE DepartmentID BETWEEN ? AND ?) GROUP BY `JobID`) AS SS)", query)
 	assertParams(assert, []interface{}{50, 100}, params)
 	assertParamOrders(assert, []interface{}{50, 100}, params)
 
@@ -1124,4 +2333,230 @@ func TestSetQueryOption(t *testing.T) {
 	assert := assert.New(t)
 	query, _ := Build(NewQuery("Users").SetQueryOption("FOR UPDATE").Select("Username"))
 	assertEqual(assert, "SELECT `Username` FROM `Users` FOR UPDATE", query)
+
+	query, _ = Build(NewQuery("Users").SetQueryOption("SQL_NO_CACHE").SetQueryOption("DISTINCT").Select("Username"))
+	assert.Equal("SELECT DISTINCT SQL_NO_CACHE `Username` FROM `Users`", query)
+
+	query, _ = Build(NewQuery("Users").SetQueryOption("LOCK IN SHARE MODE").Select("Username"))
+	assert.Equal("SELECT `Username` FROM `Users` LOCK IN SHARE MODE", query)
+
+	_, _, err := TryBuild(NewQuery("Users").SetQueryOption("IGNORE").Select())
+	assert.ErrorIs(err, ErrUnsupportedQueryOption)
+
+	_, _, err = TryBuild(NewQuery("Users").SetQueryOption("FAST").Select())
+	assert.ErrorIs(err, ErrUnknownQueryOption)
+}
+
+func TestQueryOptions(t *testing.T) {
+	assert := assert.New(t)
+	query, _ := Build(NewQuery("Users").SelectOptions(SelectCalcFoundRows, SelectNoCache, SelectStraightJoin).Distinct().Select())
+	assert.Equal("SELECT DISTINCT STRAIGHT_JOIN SQL_NO_CACHE SQL_CALC_FOUND_ROWS * FROM `Users`", query)
+
+	query, _ = Build(NewQuery("Users").InsertIgnore().InsertOptions(InsertLowPriority).Insert(H{"Username": "YamiOdymel"}))
+	assert.Equal("INSERT LOW_PRIORITY IGNORE INTO `Users` (`Username`) VALUES (?)", query)
+
+	query, _ = Build(NewQuery("Users").UpdateIgnore().UpdateOptions(UpdateLowPriority).Where("ID = ?", 1).Update(H{"Username": "YamiOdymel"}))
+	assert.Equal("UPDATE LOW_PRIORITY IGNORE `Users` SET `Username` = ? WHERE ID = ?", query)
+
+	query, _ = Build(NewQuery("Users").DeleteOptions(DeleteIgnore, DeleteQuick, DeleteQuick).Where("ID = ?", 1).Delete())
+	assert.Equal("DELETE QUICK IGNORE FROM `Users` WHERE ID = ?", query)
+
+	query, _ = Build(NewQuery("Users").Distinct().SetDialect(DialectPostgreSQL).Select())
+	assert.Equal("SELECT DISTINCT * FROM \"Users\"", query)
+
+	_, _, err := TryBuild(NewQuery("Users").InsertIgnore().Select())
+	assert.ErrorIs(err, ErrUnsupportedQueryOption)
+
+	_, _, err = TryBuild(NewQuery("Users").InsertIgnore().Replace(H{"Username": "YamiOdymel"}))
+	assert.ErrorIs(err, ErrUnsupportedQueryOption)
+
+	_, _, err = TryBuild(NewQuery("Users").SelectOptions(SelectAll).Distinct().Select())
+	assert.ErrorIs(err, ErrUnsupportedQueryOption)
+
+	_, _, err = TryBuild(NewQuery("Users").SelectOptions(SelectNoCache).SetDialect(DialectPostgreSQL).Select())
+	assert.ErrorIs(err, ErrUnsupportedQueryOption)
+
+	_, _, err = TryBuild(NewQuery("Users").SelectOptions(SelectOption("FAST")).Select())
+	assert.ErrorIs(err, ErrUnknownQueryOption)
+}
+
+func TestLock(t *testing.T) {
+	assert := assert.New(t)
+	query, _ := Build(NewQuery("Jobs").Where("Status = ?", "pending").Limit(10).ForUpdate(SkipLocked()).Select())
+	assert.Equal("SELECT * FROM `Jobs` WHERE Status = ? LIMIT 10 FOR UPDATE SKIP LOCKED", query)
+
+	query, _ = Build(NewQuery("Jobs").ForUpdate(NoWait()).Select())
+	assert.Equal("SELECT * FROM `Jobs` FOR UPDATE NOWAIT", query)
+
+	query, _ = Build(NewQuery("Jobs").InnerJoin("Workers", "Workers.ID = Jobs.WorkerID").ForShare(Of("Jobs", "Workers")).SetDialect(DialectPostgreSQL).Select())
+	assert.Equal("SELECT * FROM \"Jobs\" INNER JOIN \"Workers\" ON (Workers.ID = Jobs.WorkerID) FOR SHARE OF \"Jobs\", \"Workers\"", query)
+
+	query, _ = Build(NewQuery("Jobs").Where("Status = ?", "pending").ForUpdate(SkipLocked()).SetDialect(DialectPostgreSQL).Select())
+	assert.Equal(`SELECT * FROM "Jobs" WHERE Status = $1 FOR UPDATE SKIP LOCKED`, query)
+
+	query, _ = Build(NewQuery("Jobs").LockInShareMode().Select())
+	assert.Equal("SELECT * FROM `Jobs` LOCK IN SHARE MODE", query)
+
+	_, _, err := TryBuild(NewQuery("Jobs").ForUpdate().Update(H{"Status": "done"}))
+	assert.ErrorIs(err, ErrLockNotSelect)
+
+	_, _, err = TryBuild(NewQuery("Jobs").LockInShareMode().SetDialect(DialectPostgreSQL).Select())
+	assert.ErrorIs(err, ErrUnsupportedLock)
+
+	_, _, err = TryBuild(NewQuery("Jobs").SetQueryOption("LOCK IN SHARE MODE").SetDialect(DialectPostgreSQL).Select())
+	assert.ErrorIs(err, ErrUnsupportedLock)
+}
+
+func TestHint(t *testing.T) {
+	assert := assert.New(t)
+	query, _ := Build(NewQuery("Users").ForceIndex("idx_created").IndexHint(IgnoreIndex("idx_a").For(IndexForOrderBy)).OptimizerHint("MAX_EXECUTION_TIME(1000)").Select())
+	assert.Equal("SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM `Users` FORCE INDEX (`idx_created`) IGNORE INDEX FOR ORDER BY (`idx_a`)", query)
+
+	query, _ = Build(NewQuery("Users").LeftJoin("Posts", "Posts.UserID = Users.ID").JoinIndexHint(UseIndex("idx_user", "idx_date").For(IndexForJoin)).Select())
+	assert.Equal("SELECT * FROM `Users` LEFT JOIN `Posts` USE INDEX FOR JOIN (`idx_user`, `idx_date`) ON (Posts.UserID = Users.ID)", query)
+
+	query, _ = Build(NewQuery("Users").UseIndex("idx_status").OptimizerHint("BKA(Users)", "NO_ICP(Users)").Where("Status = ?", "banned").Update(H{"Status": "deleted"}))
+	assert.Equal("UPDATE /*+ BKA(Users) NO_ICP(Users) */ `Users` USE INDEX (`idx_status`) SET `Status` = ? WHERE Status = ?", query)
+
+	query, _ = Build(NewQuery("Users").OptimizerHint("MAX_EXECUTION_TIME(1000)").Where("ID = ?", 1).Delete())
+	assert.Equal("DELETE /*+ MAX_EXECUTION_TIME(1000) */ FROM `Users` WHERE ID = ?", query)
+
+	query, _ = Build(NewQuery("Products").UseIndex("idx_id").UpdateBatch([]H{{"ID": 1, "Price": 100}}, "ID"))
+	assert.Equal("UPDATE `Products` USE INDEX (`idx_id`) SET `Price` = CASE `ID` WHEN ? THEN ? ELSE `Price` END WHERE `ID` IN (?)", query)
+
+	_, _, err := TryBuild(NewQuery("Users").ForceIndex("idx_created").SetDialect(DialectPostgreSQL).Select())
+	assert.ErrorIs(err, ErrUnsupportedIndexHint)
+
+	_, _, err = TryBuild(NewQuery("Users").UseIndex("idx_status").Where("ID = ?", 1).Delete())
+	assert.ErrorIs(err, ErrUnsupportedIndexHint)
+
+	_, _, err = TryBuild(NewQuery("Users").LeftJoin("Posts", "Posts.UserID = Users.ID").JoinIndexHint(UseIndex("idx_user")).Insert(H{"Name": "Yami"}))
+	assert.ErrorIs(err, ErrUnsupportedIndexHint)
+
+	_, _, err = TryBuild(NewQuery("Users").OptimizerHint("BKA(Users) */ DROP TABLE Users; /*").Select())
+	assert.Error(err)
+}
+
+type testTraceKey struct{}
+
+func TestComment(t *testing.T) {
+	assert := assert.New(t)
+	query, _ := Build(NewQuery("Users").Where("ID = ?", 1).Comment("list users").Tag(map[string]string{"trace_id": "abc", "route": "/users/{id}"}).Select())
+	assert.Equal("SELECT * FROM `Users` WHERE ID = ? /* list users */ /*route='%2Fusers%2F%7Bid%7D',trace_id='abc'*/", query)
+
+	query, _ = Build(NewQuery("Users").Comment("*/ DROP TABLE Users; /*").Tag(map[string]string{"name": "O'Neil */"}).Select())
+	assert.Equal("SELECT * FROM `Users` /* * / DROP TABLE Users; / * */ /*name='O%27Neil%20%2A%2F'*/", query)
+
+	query, _ = Build(NewQuery("Users").Comment("/*/ DROP TABLE x; -- ").Select())
+	assert.Equal("SELECT * FROM `Users` /* / * / DROP TABLE x; --  */", query)
+
+	query, _ = Build(NewQuery("Users").Comment("**//**/").Select())
+	assert.Equal("SELECT * FROM `Users` /* ** // ** / */", query)
+
+	// The question marks are encoded so the interpolating drivers won't take them as the placeholders.
+	query, params := Build(NewQuery("Users").Where("ID = ?", 1).Comment("why? ??").Select())
+	assert.Equal("SELECT * FROM `Users` WHERE ID = ? /* why%3F %3F%3F */", query)
+	assert.Len(params, 1)
+
+	query, _ = Build(NewQuery("Users").Where("ID = ?", 1).Comment("exists").Exists())
+	assert.Equal("SELECT EXISTS(SELECT * FROM `Users` WHERE ID = ?) /* exists */", query)
+
+	query, _ = Build(NewRawQuery("SELECT * FROM Users WHERE ID = ?", 1).Comment("raw"))
+	assert.Equal("SELECT * FROM Users WHERE ID = ? /* raw */", query)
+
+	ContextTags = func(ctx context.Context) map[string]string {
+		if v, ok := ctx.Value(testTraceKey{}).(string); ok {
+			return map[string]string{"trace_id": v}
+		}
+		return nil
+	}
+	defer func() { ContextTags = nil }()
+	ctx := context.WithValue(context.Background(), testTraceKey{}, "xyz")
+	query, _ = Build(NewQuery("Users").TagContext(ctx).Tag(map[string]string{"route": "users"}).Select())
+	assert.Equal("SELECT * FROM `Users` /*route='users',trace_id='xyz'*/", query)
+
+	query, _ = Build(NewQuery("Users").TagContext(context.Background()).Select())
+	assert.Equal("SELECT * FROM `Users`", query)
+}
+
+func TestExplain(t *testing.T) {
+	assert := assert.New(t)
+	query, params := Build(NewQuery("Users").Where("ID = ?", 1).Explain(ExplainFormat{}).Select())
+	assert.Equal("EXPLAIN SELECT * FROM `Users` WHERE ID = ?", query)
+	assert.Equal([]interface{}{1}, params)
+
+	query, _ = Build(NewQuery("Users").Where("ID = ?", 1).Explain(ExplainFormat{Output: ExplainTree, Analyze: true}).Comment("explain").Select())
+	assert.Equal("EXPLAIN ANALYZE FORMAT=TREE SELECT * FROM `Users` WHERE ID = ? /* explain */", query)
+
+	query, _ = Build(NewQuery("Users").Where("ID = ?", 1).Explain(ExplainFormat{Output: ExplainJSON}).Update(H{"Name": "YamiOdymel"}))
+	assert.Equal("EXPLAIN FORMAT=JSON UPDATE `Users` SET `Name` = ? WHERE ID = ?", query)
+
+	query, _ = Build(NewQuery("Users").Where("ID = ?", 1).Explain(ExplainFormat{Output: ExplainJSON, Analyze: true}).SetDialect(DialectPostgreSQL).Delete())
+	assert.Equal("EXPLAIN (ANALYZE, FORMAT JSON) DELETE FROM \"Users\" WHERE ID = $1", query)
+
+	query, _ = Build(NewQuery("Users").Where("ID = ?", 1).Explain(ExplainFormat{}).Exists())
+	assert.Equal("EXPLAIN SELECT EXISTS(SELECT * FROM `Users` WHERE ID = ?)", query)
+
+	_, _, err := TryBuild(NewQuery("Users").Explain(ExplainFormat{Output: ExplainTree}).SetDialect(DialectPostgreSQL).Select())
+	assert.ErrorIs(err, ErrUnsupportedExplain)
+
+	_, _, err = TryBuild(NewQuery("Users").Explain(ExplainFormat{Output: ExplainJSON, Analyze: true}).Select())
+	assert.ErrorIs(err, ErrUnsupportedExplain)
+
+	_, _, err = TryBuild(NewRawQuery("SELECT * FROM Users").Explain(ExplainFormat{}))
+	assert.ErrorIs(err, ErrUnsupportedExplain)
+}
+
+func TestParsePlan(t *testing.T) {
+	assert := assert.New(t)
+	mysqlPlan := []byte(`{
+		"query_block": {
+			"select_id": 1,
+			"nested_loop": [
+				{"table": {"table_name": "Users", "access_type": "ALL", "rows_examined_per_scan": 1000}},
+				{"table": {"table_name": "Posts", "access_type": "ref", "key": "idx_user"}}
+			]
+		}
+	}`)
+	tables, err := ParsePlan(mysqlPlan)
+	assert.NoError(err)
+	assert.Equal([]PlanTable{
+		{Table: "Users", Access: "ALL", FullScan: true},
+		{Table: "Posts", Access: "ref"},
+	}, tables)
+	scan, _ := HasFullTableScan(mysqlPlan, "users")
+	assert.True(scan)
+	scan, _ = HasFullTableScan(mysqlPlan, "Posts")
+	assert.False(scan)
+
+	postgresPlan := []byte(`[{"Plan": {"Node Type": "Nested Loop", "Plans": [
+		{"Node Type": "Index Scan", "Relation Name": "users", "Index Name": "users_pkey"},
+		{"Node Type": "Seq Scan", "Relation Name": "posts"}
+	]}}]`)
+	scan, _ = HasFullTableScan(postgresPlan, "posts")
+	assert.True(scan)
+	scan, _ = HasFullTableScan(postgresPlan, "users")
+	assert.False(scan)
+
+	// The keys of the objects are walked in order, so the order of the tables is stable.
+	unionPlan := []byte(`{"query_block": {"union_result": {"query_specifications": [
+		{"query_block": {"table": {"table_name": "Users", "access_type": "ALL"}}}
+	]}, "ordering_operation": {"table": {"table_name": "Admins", "access_type": "ALL"}}}}`)
+	for i := 0; i < 10; i++ {
+		tables, err = ParsePlan(unionPlan)
+		assert.NoError(err)
+		assert.Equal([]PlanTable{
+			{Table: "Admins", Access: "ALL", FullScan: true},
+			{Table: "Users", Access: "ALL", FullScan: true},
+		}, tables)
+	}
+
+	treePlan := []byte("-> Nested loop inner join  (cost=451.75 rows=1000)\n    -> Table scan on Users  (cost=101.75 rows=1000)\n    -> Index lookup on Posts using idx_user (UserID=Users.ID)  (cost=0.25 rows=1)")
+	scan, _ = HasFullTableScan(treePlan, "Users")
+	assert.True(scan)
+	scan, _ = HasFullTableScan(treePlan, "Posts")
+	assert.False(scan)
+
+	_, err = ParsePlan([]byte(`{"query_block":`))
+	assert.Error(err)
 }
@@ -1,35 +1,84 @@
 package rushia
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"runtime"
 	"strings"
 )
 
+var (
+	// ErrFullTable is returned when an `UPDATE`, `PATCH` or `DELETE` query has no `WHERE` condition while `RequireWhere` is enabled.
+	ErrFullTable = errors.New("rushia: refused to update or delete the full table without WHERE condition, call AllowFullTable if it was intended")
+)
+
+// RowValues builds the tuple conditions of `WhereIn` with the row values (e.g. `(a, b) IN ((?, ?), (?, ?))`),
+// disable it for the databases that don't support the row values, so they will be built as `(a = ? AND b = ?) OR (...)`.
+var RowValues = true
+
+// TupleChunkSize is the maximum count of the tuples in a row-value `IN` list,
+// the larger list will be split into multiple `IN` lists that are connected with `OR`.
+var TupleChunkSize = 1000
+
+// RequireWhere refuses to build the `UPDATE`, `PATCH` and `DELETE` queries without `WHERE` condition,
+// unless `AllowFullTable` was called on the query.
+var RequireWhere = false
+
 // Expr
 type Expr struct {
 	rawQuery string
 	params   []interface{}
+	err      error
 }
 
 // H
 type H map[string]interface{}
 
+// QueryType is the type of the query (e.g. `SELECT`, `UPDATE`, `DELETE`).
+type QueryType int
+
 const (
-	queryTypeUnknown queryType = iota
-	queryTypeInsert
-	queryTypeReplace
-	queryTypeUpdate
-	queryTypeSelect
-	queryTypePatch
-	queryTypeExists
-	queryTypeInsertSelect
-	queryTypeRawQuery
-	queryTypeSubQuery
-	queryTypeDelete
+	QueryTypeUnknown QueryType = iota
+	QueryTypeInsert
+	QueryTypeReplace
+	QueryTypeUpdate
+	QueryTypeSelect
+	QueryTypePatch
+	QueryTypeExists
+	QueryTypeInsertSelect
+	QueryTypeRawQuery
+	QueryTypeSubQuery
+	QueryTypeDelete
 )
 
-type queryType int
+// String returns the name of the query type.
+func (t QueryType) String() string {
+	switch t {
+	case QueryTypeInsert:
+		return "INSERT"
+	case QueryTypeReplace:
+		return "REPLACE"
+	case QueryTypeUpdate:
+		return "UPDATE"
+	case QueryTypeSelect:
+		return "SELECT"
+	case QueryTypePatch:
+		return "PATCH"
+	case QueryTypeExists:
+		return "EXISTS"
+	case QueryTypeInsertSelect:
+		return "INSERT SELECT"
+	case QueryTypeRawQuery:
+		return "RAW"
+	case QueryTypeSubQuery:
+		return "SUB QUERY"
+	case QueryTypeDelete:
+		return "DELETE"
+	default:
+		return "UNKNOWN"
+	}
+}
 
 const (
 	connectorTypeAnd connectorType = iota
@@ -92,12 +141,24 @@ type condition struct {
 	query     string
 	args      []interface{}
 	connector connectorType
+
+	// group is the nested conditions that will be wrapped in the parentheses.
+	group []condition
+	// tuple is the row-value `IN` condition of the composite columns.
+	tuple *tuple
+}
+
+// tuple is a row-value `IN` condition (e.g. `(a, b) IN ((?, ?), (?, ?))`).
+type tuple struct {
+	columns []string
+	values  interface{}
 }
 
 type join struct {
-	table    string
-	subQuery *Query
-	typ      joinType
+	table      interface{}
+	subQuery   *Query
+	typ        joinType
+	indexHints []IndexHint
 
 	conditions []condition
 }
@@ -112,12 +173,56 @@ type offset struct {
 	offset int
 }
 
+// OrderDirection is the sorting direction of the `ORDER BY` option.
+type OrderDirection string
+
+const (
+	// OrderAsc sorts in ascending order.
+	OrderAsc OrderDirection = "ASC"
+	// OrderDesc sorts in descending order.
+	OrderDesc OrderDirection = "DESC"
+)
+
+const (
+	nullsTypeDefault nullsType = iota
+	nullsTypeFirst
+	nullsTypeLast
+)
+
+type nullsType int
+
+func (t nullsType) toQuery() string {
+	switch t {
+	case nullsTypeFirst:
+		return "FIRST"
+	case nullsTypeLast:
+		return "LAST"
+	default:
+		return ""
+	}
+}
+
+const (
+	groupModifierNone groupModifier = iota
+	groupModifierRollup
+	groupModifierCube
+)
+
+type groupModifier int
+
 type order struct {
-	field  string
+	field  interface{}
 	values []interface{}
 
+	column    interface{}
+	direction OrderDirection
+	nulls     nullsType
+}
+
+// assignment is a column assignment that will be appended to the `SET` or the `ON DUPLICATE KEY UPDATE` list.
+type assignment struct {
 	column string
-	// sort orderSortType
+	value  interface{}
 }
 
 type exclude struct {
@@ -134,13 +239,13 @@ type union struct {
 type Query struct {
 	alias string
 
-	typ      queryType
+	typ      QueryType
 	subQuery *Query
 
 	table        interface{}
 	wheres       []condition
 	havings      []condition
-	queryOptions []string
+	queryOptions []queryOption
 
 	unions []union
 
@@ -148,21 +253,52 @@ type Query struct {
 
 	selects []interface{}
 
-	joins     []join
-	duplicate H
+	joins       []join
+	duplicate   H
+	assignments []assignment
+
+	// batchKey is the key column of the rows of `UpdateBatch`.
+	batchKey string
 
 	limit  limit
 	offset offset
 
 	orders []order
 
-	groups []string
+	groups        []interface{}
+	groupingSets  [][]interface{}
+	groupModifier groupModifier
 
 	rawQuery string
 	params   []interface{}
 
 	omits   []string
 	exclude exclude
+
+	model *model
+
+	timestamps timestamps
+	softDelete softDelete
+
+	scopes   []Scope
+	unscoped bool
+
+	allowFullTable bool
+
+	lock *lock
+
+	indexHints     []IndexHint
+	optimizerHints []string
+
+	comments []string
+	tags     map[string]string
+
+	explain *ExplainFormat
+
+	dialect Dialect
+
+	// err is the first error that occurred while creating the query, it will be returned while building.
+	err error
 }
 
 // NewQuery creates a Query based on a table name or a sub query.
@@ -174,34 +310,80 @@ func NewQuery(table interface{}) *Query {
 }
 
 // NewRawQuery creates a Query based on the passed in raw query and the parameters.
+// The `??` symbols will be replaced with the escaped identifiers,
+// pass a `Named` as the only parameter to use the named parameters (e.g. `:id`) in the query.
 func NewRawQuery(q string, params ...interface{}) *Query {
-	if strings.Contains(q, "??") {
-		panic("rushia: raw query doesn't support escape ?? sign yet")
-	}
+	q, params, err := escapeArgs(q, params, DialectMySQL)
 	return &Query{
-		typ:      queryTypeRawQuery,
+		typ:      QueryTypeRawQuery,
 		rawQuery: q,
 		params:   params,
+		err:      err,
 	}
 }
 
 // NewExpr creates an Expression that accepts raw query and the parameters. Could be useful as the value if you are representing a complex query.
+// Pass a `Named` as the only parameter to use the named parameters (e.g. `:id`) in the query.
 func NewExpr(query string, params ...interface{}) *Expr {
+	query, params, err := processNamed(query, params, DialectMySQL)
 	return &Expr{
 		rawQuery: query,
 		params:   params,
+		err:      err,
 	}
 }
 
-// NewAlias creates an alias for a table.
+// NewAlias creates an alias for a table, use `Col(table).As(alias)` instead if `StrictIdentifiers` was enabled.
 func NewAlias(table string, alias string) string {
-	return fmt.Sprintf("`%s` AS %s", table, alias)
+	return fmt.Sprintf("%s AS %s", quoteIdent(table), alias)
 }
 
-// Build builds the Query.
+// Build builds the Query, the identifiers and the placeholders will be converted into the forms of the dialect
+// (e.g. `"Users"` and `$1` in PostgreSQL).
 func Build(q *Query) (query string, params []interface{}) {
+	query, params = build(q)
+	return formatQuery(query, q.dialect), params
+}
+
+// build builds the Query with the backtick-quoted identifiers and the `?` placeholders,
+// the sub queries are built by it as well so the placeholders will be numbered once by `Build`.
+func build(q *Query) (query string, params []interface{}) {
+	// Build with a copy so the behaviours won't be applied to the original query.
+	q = q.Copy()
+	if q.err != nil {
+		panic(q.err)
+	}
+	if err := q.checkFullTable(); err != nil {
+		panic(err)
+	}
+	if err := q.checkLock(); err != nil {
+		panic(err)
+	}
+	if len(q.assignments) != 0 {
+		if err := q.checkAssignments(); err != nil {
+			panic(err)
+		}
+	}
+	if err := q.checkExplain(); err != nil {
+		panic(err)
+	}
+	if err := q.checkIndexHints(); err != nil {
+		panic(err)
+	}
+	if err := q.applyScopes(); err != nil {
+		panic(err)
+	}
+	q.applySoftDelete()
+	// Check the options after the soft delete so the options of the rewritten query won't be dropped silently.
+	if err := q.checkOptions(); err != nil {
+		panic(err)
+	}
+	q.applyTimestamps()
+
+	query += q.padSpace(q.buildExplain())
 	query += q.padSpace(q.buildQuery())
-	if q.typ == queryTypeRawQuery || q.typ == queryTypeExists {
+	if q.typ == QueryTypeRawQuery || q.typ == QueryTypeExists {
+		query += q.padSpace(q.buildComment())
 		return q.trim(query), q.params
 	}
 	query += q.padSpace(q.buildAs())
@@ -209,11 +391,36 @@ func Build(q *Query) (query string, params []interface{}) {
 	query += q.padSpace(q.buildUnion())
 	query += q.padSpace(q.buildJoin())
 	query += q.padSpace(q.buildWhere())
+	query += q.padSpace(q.buildGroupBy())
 	query += q.padSpace(q.buildHaving())
 	query += q.padSpace(q.buildOrderBy())
-	query += q.padSpace(q.buildGroupBy())
 	query += q.padSpace(q.buildLimit())
 	query += q.padSpace(q.buildOffset())
-	query += q.padSpace(q.buildAfterQueryOptions())
+	query += q.padSpace(q.buildLock())
+	query += q.padSpace(q.buildComment())
 	return q.trim(query), q.params
 }
+
+// TryBuild works the same as `Build` but returns an error instead of panicking
+// if the query was rejected by a scope or it was incorrectly used.
+func TryBuild(q *Query) (query string, params []interface{}, err error) {
+	defer func() {
+		r := recover()
+		switch v := r.(type) {
+		case nil:
+		case runtime.Error:
+			panic(v)
+		case error:
+			err = v
+		case string:
+			if !strings.HasPrefix(v, "rushia: ") {
+				panic(v)
+			}
+			err = errors.New(v)
+		default:
+			panic(v)
+		}
+	}()
+	query, params = Build(q)
+	return
+}
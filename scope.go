@@ -0,0 +1,166 @@
+package rushia
+
+import (
+	"strings"
+	"sync"
+)
+
+// Scope transforms the query right before it's being built, it's able to add the conditions, rewrite the table,
+// or reject the query by returning an error. The existing `OR` conditions will be grouped in the parentheses before the scopes are applied.
+type Scope func(q *Query) error
+
+var (
+	scopesMutex  sync.RWMutex
+	tableScopes  = make(map[string][]Scope)
+	globalScopes []Scope
+)
+
+// RegisterScope registers a scope for the specified table, the scope will be applied to every query of the table,
+// including the aliased table (e.g. `Orders AS o`) and the table with the schema (e.g. `shop.Orders`).
+//
+// The scope will also be applied to the joined tables, the conditions that were added by the scope will be appended
+// to the joining conditions. Qualify the columns with `TableRef` (e.g. `q.Where("?? = ?", q.TableRef()+".tenant_id", id)`)
+// so they won't be ambiguous with the other tables.
+func RegisterScope(table string, s Scope) {
+	scopesMutex.Lock()
+	defer scopesMutex.Unlock()
+	tableScopes[table] = append(tableScopes[table], s)
+}
+
+// RegisterGlobalScope registers a scope that will be applied to every query.
+func RegisterGlobalScope(s Scope) {
+	scopesMutex.Lock()
+	defer scopesMutex.Unlock()
+	globalScopes = append(globalScopes, s)
+}
+
+// ClearScopes removes all the registered scopes.
+func ClearScopes() {
+	scopesMutex.Lock()
+	defer scopesMutex.Unlock()
+	tableScopes = make(map[string][]Scope)
+	globalScopes = nil
+}
+
+// Scope adds the scopes to the current query only, the scopes will be applied after the registered scopes.
+func (q *Query) Scope(scopes ...Scope) *Query {
+	q.scopes = append(q.scopes, scopes...)
+	return q
+}
+
+// When calls the function with the query if the condition is true, or calls the otherwise function if it was passed.
+// It's useful for the optional filters (e.g. `q.When(name != "", func(q *Query) { q.Where("Name = ?", name) })`).
+func (q *Query) When(condition bool, fn func(q *Query), otherwise ...func(q *Query)) *Query {
+	if condition {
+		fn(q)
+	} else if len(otherwise) != 0 {
+		otherwise[0](q)
+	}
+	return q
+}
+
+// Unless calls the function with the query if the condition is false.
+func (q *Query) Unless(condition bool, fn func(q *Query)) *Query {
+	return q.When(!condition, fn)
+}
+
+// Apply calls the functions with the query in order right away, so the reusable filters could be shared across the packages.
+// Unlike `Scope`, the functions are applied immediately instead of while building.
+func (q *Query) Apply(fns ...func(q *Query) *Query) *Query {
+	for _, fn := range fns {
+		if r := fn(q); r != nil {
+			q = r
+		}
+	}
+	return q
+}
+
+// Unscoped skips the registered scopes and the soft delete for the current query,
+// the scopes that were added by `Scope` will still be applied.
+func (q *Query) Unscoped() *Query {
+	q.unscoped = true
+	return q
+}
+
+// Type returns the type of the query.
+func (q *Query) Type() QueryType {
+	return q.typ
+}
+
+// SetTable replaces the table of the query with a table name or a sub query.
+func (q *Query) SetTable(table interface{}) *Query {
+	q.table = table
+	return q
+}
+
+// applyScopes applies the global scopes, the scopes of the table, and the scopes of the query in order.
+func (q *Query) applyScopes() error {
+	// The `EXISTS` query will be scoped by its inner `SELECT` query.
+	if q.typ == QueryTypeExists {
+		return nil
+	}
+	var scopes []Scope
+	if !q.unscoped {
+		scopesMutex.RLock()
+		scopes = append(scopes, globalScopes...)
+		scopes = append(scopes, scopesOf(q.table)...)
+		scopesMutex.RUnlock()
+
+		if err := q.applyJoinScopes(); err != nil {
+			return err
+		}
+	}
+	scopes = append(scopes, q.scopes...)
+	if len(scopes) == 0 {
+		return nil
+	}
+	// Group the conditions so the conditions from the scopes won't be bypassed by an `OR` condition.
+	q.groupWheres()
+
+	for _, s := range scopes {
+		if err := s(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyJoinScopes applies the scopes of the joined tables, the conditions that were added by the scopes will be appended to the joining conditions.
+func (q *Query) applyJoinScopes() error {
+	for i, v := range q.joins {
+		if v.subQuery != nil {
+			continue
+		}
+		scopesMutex.RLock()
+		scopes := scopesOf(v.table)
+		scopesMutex.RUnlock()
+		if len(scopes) == 0 {
+			continue
+		}
+		jq := NewQuery(v.table).SetDialect(q.dialect)
+		jq.typ = QueryTypeSelect
+		for _, s := range scopes {
+			if err := s(jq); err != nil {
+				return err
+			}
+		}
+		q.joins[i].conditions = mergeConditions(v.conditions, jq.wheres)
+	}
+	return nil
+}
+
+// scopesOf returns the registered scopes of the table, the table name without the schema will be used if the full name has no scopes.
+// The scopes mutex must be held by the caller.
+func scopesOf(table interface{}) []Scope {
+	name, _ := parseTable(table)
+	if name == "" {
+		return nil
+	}
+	if scopes, ok := tableScopes[name]; ok {
+		return scopes
+	}
+	if i := strings.LastIndex(name, "."); i != -1 {
+		return tableScopes[name[i+1:]]
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package rushia
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// NowFunc returns the current time for the timestamps and the soft delete,
+// replace it to control the time in the tests.
+var NowFunc = time.Now
+
+const (
+	trashedTypeWithout trashedType = iota
+	trashedTypeWith
+	trashedTypeOnly
+)
+
+type trashedType int
+
+type timestamps struct {
+	enabled   bool
+	createdAt string
+	updatedAt string
+}
+
+type softDelete struct {
+	enabled bool
+	column  string
+	trashed trashedType
+}
+
+// Timestamps fills the `created_at` and `updated_at` columns automatically,
+// the creation time will be filled while inserting, and the update time will be filled while inserting, updating or patching.
+// Pass the column names to replace the default `created_at` and `updated_at` columns.
+func (q *Query) Timestamps(columns ...string) *Query {
+	q.timestamps = timestamps{
+		enabled:   true,
+		createdAt: "created_at",
+		updatedAt: "updated_at",
+	}
+	if len(columns) > 0 {
+		q.timestamps.createdAt = columns[0]
+	}
+	if len(columns) > 1 {
+		q.timestamps.updatedAt = columns[1]
+	}
+	return q
+}
+
+// SoftDelete turns the `DELETE` query into an `UPDATE` query that sets the `deleted_at` column to the current time,
+// and the deleted rows will be excluded from the `SELECT`, `UPDATE` queries unless `WithTrashed` or `OnlyTrashed` was called.
+// Call `Unscoped` to ignore the soft delete and delete the rows permanently.
+// Pass the column name to replace the default `deleted_at` column.
+func (q *Query) SoftDelete(column ...string) *Query {
+	q.softDelete.enabled = true
+	q.softDelete.column = "deleted_at"
+	if len(column) > 0 {
+		q.softDelete.column = column[0]
+	}
+	return q
+}
+
+// WithTrashed includes the soft deleted rows in the query.
+func (q *Query) WithTrashed() *Query {
+	q.softDelete.trashed = trashedTypeWith
+	return q
+}
+
+// OnlyTrashed limits the query to the soft deleted rows only.
+func (q *Query) OnlyTrashed() *Query {
+	q.softDelete.trashed = trashedTypeOnly
+	return q
+}
+
+// applySoftDelete rewrites the `DELETE` query into an `UPDATE` query and filters the soft deleted rows.
+func (q *Query) applySoftDelete() {
+	if !q.softDelete.enabled || q.unscoped {
+		return
+	}
+	switch q.typ {
+	case QueryTypeDelete:
+		q.typ = QueryTypeUpdate
+		q.data = H{q.softDelete.column: NowFunc()}
+	// The `EXISTS` query will be filtered by its inner `SELECT` query.
+	case QueryTypeSelect, QueryTypeUpdate, QueryTypePatch:
+	default:
+		return
+	}
+	// Qualify the column with the table so it won't be ambiguous with the joined tables.
+	column := q.softDelete.column
+	if ref := tableRef(q.table); ref != "" {
+		column = fmt.Sprintf("%s.%s", ref, column)
+	}
+	switch q.softDelete.trashed {
+	case trashedTypeWithout:
+		q.whereAll("?? IS NULL", column)
+	case trashedTypeOnly:
+		q.whereAll("?? IS NOT NULL", column)
+	}
+}
+
+// applyTimestamps fills the timestamp columns into the data of the query.
+func (q *Query) applyTimestamps() {
+	if !q.timestamps.enabled {
+		return
+	}
+	var isInsert bool
+	switch q.typ {
+	case QueryTypeInsert, QueryTypeReplace:
+		isInsert = true
+	case QueryTypeUpdate, QueryTypePatch:
+	default:
+		return
+	}
+	now := NowFunc()
+	_, _, datas := q.explodeData(q.data, []string{})
+	for i, v := range datas {
+		h := make(H, len(v)+2)
+		for k, j := range v {
+			h[k] = j
+		}
+		if isInsert {
+			fillZero(h, q.timestamps.createdAt, now)
+			fillZero(h, q.timestamps.updatedAt, now)
+		} else {
+			h[q.timestamps.updatedAt] = now
+		}
+		datas[i] = h
+	}
+	q.data = datas
+
+	if isInsert && (q.duplicate != nil || len(q.assignments) != 0) {
+		duplicate := make(H, len(q.duplicate)+1)
+		for k, j := range q.duplicate {
+			duplicate[k] = j
+		}
+		fillZero(duplicate, q.timestamps.updatedAt, now)
+		q.duplicate = duplicate
+	}
+}
+
+// fillZero sets the value to the key of the data if the key doesn't exist or it's a zero value.
+func fillZero(h H, k string, v interface{}) {
+	if j, ok := h[k]; ok && j != nil && !reflect.ValueOf(j).IsZero() {
+		return
+	}
+	h[k] = v
+}